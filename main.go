@@ -10,10 +10,13 @@ import (
 	"syscall"
 
 	"github.com/l10n-center/api/src"
+	"github.com/l10n-center/api/src/auth"
 	"github.com/l10n-center/api/src/config"
 	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/mail"
 	"github.com/l10n-center/api/src/store"
 	"github.com/l10n-center/api/src/tracing"
+	"github.com/l10n-center/api/src/users"
 
 	"github.com/tomazk/envcfg"
 	"go.uber.org/zap"
@@ -44,12 +47,39 @@ func main() {
 		l.Fatal(err.Error(), errs.ZapStack(err))
 	}
 
+	mailer, err := mail.New(cfg)
+	if err != nil {
+		l.Fatal(err.Error(), errs.ZapStack(err))
+	}
+
+	if err = auth.CheckUnverifiedPolicy(cfg); err != nil {
+		l.Fatal(err.Error(), errs.ZapStack(err))
+	}
+
+	keys, err := auth.NewKeyring(cfg)
+	if err != nil {
+		l.Fatal(err.Error(), errs.ZapStack(err))
+	}
+
+	authn, err := auth.NewAuthenticator(cfg, store)
+	if err != nil {
+		l.Fatal(err.Error(), errs.ZapStack(err))
+	}
+
 	s := &http.Server{
 		Addr:     cfg.Bind,
-		Handler:  api.NewRouter(cfg, store),
+		Handler:  api.NewRouter(cfg, keys, authn, store, mailer),
 		ErrorLog: zap.NewStdLog(zap.L()),
 	}
 
+	jCtx, jCancel := context.WithCancel(context.Background())
+
+	defer jCancel()
+
+	if cfg.UserRetention > 0 {
+		go users.Retention(jCtx, cfg, store)
+	}
+
 	l.Info("starting http service")
 	go s.ListenAndServe()
 
@@ -57,6 +87,7 @@ func main() {
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
 	<-c
 	l.Info("stopping http service")
+	jCancel()
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
 		<-c
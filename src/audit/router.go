@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// DefaultLimit of entries per page
+	DefaultLimit = 50
+	// MaxLimit of entries per page
+	MaxLimit = 500
+)
+
+// Router return audit section router
+//
+// All routes are available only for admins. Entries are recorded
+// by handlers of other sections with auth.Audit
+func Router(cfg *config.Config, keys *auth.Keyring, store Store) func(chi.Router) {
+
+	return func(r chi.Router) {
+		r.Use(auth.WithClaims(cfg, keys, store, true, true))
+
+		r.Get("/", List(cfg, store))
+	}
+}
+
+// Page of audit entries with cursor of next page
+type Page struct {
+	Entries    []*model.AuditEntry `json:"entries"`
+	NextCursor string              `json:"nextCursor,omitempty"`
+}
+
+// parseQuery of audit entries from url query
+//
+// actor and cursor are ids, from and to are RFC 3339 times
+func parseQuery(r *http.Request) (*model.AuditQuery, string) {
+	v := r.URL.Query()
+	q := &model.AuditQuery{Action: v.Get("action"), Limit: DefaultLimit}
+	for param, id := range map[string]*bson.ObjectId{"actor": &q.ActorID, "cursor": &q.Cursor} {
+		if s := v.Get(param); s != "" {
+			if !bson.IsObjectIdHex(s) {
+				return nil, param + ": must be an id"
+			}
+			*id = bson.ObjectIdHex(s)
+		}
+	}
+	for param, t := range map[string]**time.Time{"from": &q.From, "to": &q.To} {
+		if s := v.Get(param); s != "" {
+			at, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, param + ": must be RFC 3339 time"
+			}
+			*t = &at
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return nil, "limit: must be from 1 to " + strconv.Itoa(MaxLimit)
+		}
+		q.Limit = limit
+	}
+
+	return q, ""
+}
+
+// List audit entries from newest to oldest filtered by actor, action
+// and time range
+func List(_ *config.Config, store Store) http.HandlerFunc {
+	// List audit entries
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		q, msg := parseQuery(r)
+		if q == nil {
+			l.Debug(msg)
+			http.Error(w, "validate: "+msg, http.StatusBadRequest)
+			return
+		}
+		el, err := store.GetAuditEntries(ctx, q)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		p := &Page{Entries: el}
+		if len(el) == q.Limit {
+			p.NextCursor = el[len(el)-1].ID.Hex()
+		}
+		render.JSON(w, r, p)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,156 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/audit"
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pressly/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func withNoopSpan(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		sp := opentracing.NoopTracer{}.StartSpan(r.URL.Path)
+		ctx := opentracing.ContextWithSpan(r.Context(), sp)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func TestRouter(t *testing.T) {
+	admin := &model.User{ID: bson.NewObjectId(), Email: "admin@email.com", IsAdmin: true}
+	user := &model.User{ID: bson.NewObjectId(), Email: "user@email.com"}
+	entries := []*model.AuditEntry{{ID: bson.NewObjectId()}, {ID: bson.NewObjectId()}}
+	cursor := bson.NewObjectId()
+	from := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		store  func(*gomock.Controller) *MockStore
+		user   *model.User
+		path   string
+		code   int
+		cursor string
+	}{
+		{
+			name: "Not admin",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user: user,
+			path: "/audit",
+			code: http.StatusForbidden,
+		},
+		{
+			name: "Bad actor",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user: admin,
+			path: "/audit?actor=admin",
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Bad time",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user: admin,
+			path: "/audit?from=yesterday",
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Last page",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetAuditEntries(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, q *model.AuditQuery) {
+						assert.Equal(t, audit.DefaultLimit, q.Limit)
+					}).
+					Return(entries, nil)
+
+				return store
+			},
+			user: admin,
+			path: "/audit",
+			code: http.StatusOK,
+		},
+		{
+			name: "Filtered page",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetAuditEntries(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, q *model.AuditQuery) {
+						assert.Equal(t, &model.AuditQuery{
+							ActorID: user.ID,
+							Action:  "login.fail",
+							From:    &from,
+							Cursor:  cursor,
+							Limit:   2,
+						}, q)
+					}).
+					Return(entries, nil)
+
+				return store
+			},
+			user:   admin,
+			path:   "/audit?actor=" + user.ID.Hex() + "&action=login.fail&from=2017-01-01T00:00:00Z&cursor=" + cursor.Hex() + "&limit=2",
+			code:   http.StatusOK,
+			cursor: entries[1].ID.Hex(),
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(withNoopSpan)
+			store := c.store(mockCtrl)
+			r.Route("/audit", audit.Router(cfg, keys, store))
+
+			req := httptest.NewRequest("GET", c.path, nil)
+			st, err := auth.CreateToken(context.Background(), keys, store, c.user)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+st)
+			store.EXPECT().
+				IsTokenRevoked(gomock.Any(), gomock.Any()).
+				Return(false, nil)
+			store.EXPECT().
+				GetUserByID(gomock.Any(), c.user.ID).
+				Return(c.user, nil)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			if c.code == http.StatusOK {
+				p := &audit.Page{}
+				require.NoError(t, json.NewDecoder(res.Body).Decode(p))
+				assert.Equal(t, c.cursor, p.NextCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/model"
+)
+
+//go:generate mockgen -source=store.go -destination=store_mock_test.go -package=audit_test -aux_files=auth=../auth/store.go
+
+// Store is a interface of store required in package audit
+type Store interface {
+	auth.ClaimsStore
+	GetAuditEntries(context.Context, *model.AuditQuery) ([]*model.AuditEntry, error)
+}
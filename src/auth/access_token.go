@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AccessTokenPrefix distinguish personal access tokens from jwt
+const AccessTokenPrefix = "l10n_"
+
+// interactiveClaims return claims of request authorized by jwt
+//
+// Personal access tokens can't be used to manage tokens and sessions,
+// so response is written and false returned for them
+func interactiveClaims(w http.ResponseWriter, r *http.Request) (*Claims, bool) {
+	l := tracing.Logger(r.Context())
+
+	c, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		l.Debug("no claims found")
+		http.Error(w, "login required", http.StatusUnauthorized)
+		return nil, false
+	}
+	if c.TokenID != "" {
+		l.Debug("access token used for interactive operation")
+		http.Error(w, "not allowed for access token", http.StatusForbidden)
+		return nil, false
+	}
+
+	return c, true
+}
+
+// AccessTokens list personal access tokens of current user
+func AccessTokens(_ *config.Config, store Store) http.HandlerFunc {
+	// List personal access tokens of current user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		c, ok := interactiveClaims(w, r)
+		if !ok {
+			return
+		}
+		atl, err := store.GetAccessTokensByUser(ctx, c.UserID)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, atl)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// CreateAccessToken create personal access token of current user
+//
+// Token is returned only once in response and stored hashed
+func CreateAccessToken(_ *config.Config, store Store) http.HandlerFunc {
+	// Create personal access token of current user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		c, ok := interactiveClaims(w, r)
+		if !ok {
+			return
+		}
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Name       string           `json:"name" valid:"required"`
+			Permission model.Permission `json:"permission"`
+			ExpiresAt  *time.Time       `json:"expiresAt"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		if rd.ExpiresAt != nil && rd.ExpiresAt.Before(time.Now()) {
+			l.Debug("expiration in past")
+			http.Error(w, "validate: expiresAt: must be in future", http.StatusBadRequest)
+			return
+		}
+		u, err := store.GetUserByID(ctx, c.UserID)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		perm, _, err := UserPermission(ctx, store, u)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if rd.Permission&^perm != 0 {
+			l.Debug("token permission exceeds user permission")
+			http.Error(w, "validate: permission: exceeds user permission", http.StatusBadRequest)
+			return
+		}
+		token, hash, err := NewRandomToken()
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		at := &model.AccessToken{
+			ID:         bson.NewObjectId(),
+			UserID:     u.ID,
+			Name:       rd.Name,
+			Hash:       hash,
+			Permission: rd.Permission,
+			CreatedAt:  time.Now(),
+			ExpiresAt:  rd.ExpiresAt,
+		}
+		if err = store.CreateAccessToken(ctx, at); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		Audit(ctx, store, r, "token.create", at.ID.Hex(), nil, at)
+		l.Sugar().Infof("access token <%s> created by <%s>", at.Name, u.Email)
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, &struct {
+			*model.AccessToken
+			Token string `json:"token"`
+		}{at, AccessTokenPrefix + token})
+	}
+	return http.HandlerFunc(fn)
+}
+
+// DeleteAccessToken revoke personal access token of current user
+func DeleteAccessToken(_ *config.Config, store Store) http.HandlerFunc {
+	// Revoke personal access token of current user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		c, ok := interactiveClaims(w, r)
+		if !ok {
+			return
+		}
+		id := chi.URLParam(r, "id")
+		if !bson.IsObjectIdHex(id) {
+			l.Debug("bad token id")
+			http.Error(w, "access token not found", http.StatusNotFound)
+			return
+		}
+		err := store.DeleteAccessToken(ctx, c.UserID, bson.ObjectIdHex(id))
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "access token not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		Audit(ctx, store, r, "token.delete", id, nil, nil)
+		l.Sugar().Infof("access token <%s> deleted by <%s>", id, c.Email)
+		http.Error(w, "access token deleted", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
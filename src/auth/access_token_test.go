@@ -0,0 +1,280 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestWithAccessToken(t *testing.T) {
+	u := &model.User{
+		ID:         bson.NewObjectId(),
+		Email:      "test@email.com",
+		IsAdmin:    true,
+		Permission: model.CanRead | model.CanAppend,
+	}
+	past := time.Now().Add(-time.Minute)
+
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockClaimsStore
+		code  int
+	}{
+		{
+			name: "Unknown token",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					GetAccessTokenByHash(gomock.Any(), gomock.Any()).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name: "Expired token",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					GetAccessTokenByHash(gomock.Any(), gomock.Any()).
+					Return(&model.AccessToken{ID: bson.NewObjectId(), UserID: u.ID, ExpiresAt: &past}, nil)
+
+				return store
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name: "Valid token",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				at := &model.AccessToken{
+					ID:         bson.NewObjectId(),
+					UserID:     u.ID,
+					Permission: model.CanRead | model.CanEdit,
+				}
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					GetAccessTokenByHash(gomock.Any(), gomock.Any()).
+					Return(at, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+				store.EXPECT().
+					TouchAccessToken(gomock.Any(), at.ID, gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			code: http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+
+			handler := auth.WithClaims(cfg, keys, store, true, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c, ok := auth.ClaimsFromContext(r.Context())
+				require.True(t, ok)
+				assert.False(t, c.IsAdmin)
+				assert.Equal(t, model.CanRead, c.Permission)
+				assert.NotEmpty(t, c.TokenID)
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req = req.WithContext(opentracing.ContextWithSpan(req.Context(), opentracing.NoopTracer{}.StartSpan("test")))
+			req.Header.Set("Authorization", "Bearer "+auth.AccessTokenPrefix+"token")
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
+
+func TestWithServiceToken(t *testing.T) {
+	sa := &model.ServiceAccount{
+		ID:         bson.NewObjectId(),
+		Name:       "ci",
+		Permission: model.CanRead | model.CanAppend,
+		Languages:  []string{"de"},
+	}
+	at := &model.AccessToken{ID: bson.NewObjectId(), UserID: sa.ID, Service: true}
+
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockClaimsStore
+		code  int
+	}{
+		{
+			name: "Deleted account",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					GetAccessTokenByHash(gomock.Any(), gomock.Any()).
+					Return(at, nil)
+				store.EXPECT().
+					GetServiceAccountByID(gomock.Any(), sa.ID).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name: "Valid token",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					GetAccessTokenByHash(gomock.Any(), gomock.Any()).
+					Return(at, nil)
+				store.EXPECT().
+					GetServiceAccountByID(gomock.Any(), sa.ID).
+					Return(sa, nil)
+				store.EXPECT().
+					TouchAccessToken(gomock.Any(), at.ID, gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			code: http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+
+			handler := auth.WithClaims(cfg, keys, store, true, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c, ok := auth.ClaimsFromContext(r.Context())
+				require.True(t, ok)
+				assert.True(t, c.Service)
+				assert.False(t, c.IsAdmin)
+				assert.Equal(t, sa.ID, c.UserID)
+				assert.Equal(t, sa.Permission, c.Permission)
+				assert.Equal(t, sa.Languages, c.Languages)
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req = req.WithContext(opentracing.ContextWithSpan(req.Context(), opentracing.NoopTracer{}.StartSpan("test")))
+			req.Header.Set("Authorization", "Bearer "+auth.AccessTokenPrefix+"token")
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
+
+func TestCreateAccessToken(t *testing.T) {
+	u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com", Permission: model.CanRead | model.CanAppend}
+
+	cases := []struct {
+		name   string
+		store  func(*gomock.Controller) *MockStore
+		claims *auth.Claims
+		body   string
+		code   int
+	}{
+		{
+			name: "By access token",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			claims: &auth.Claims{UserID: u.ID, TokenID: bson.NewObjectId()},
+			body:   `{"name":"ci"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Exceed permission",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+
+				return store
+			},
+			claims: &auth.Claims{UserID: u.ID},
+			body:   `{"name":"ci","permission":6}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Created",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+				store.EXPECT().
+					CreateAccessToken(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, at *model.AccessToken) {
+						require.Equal(t, u.ID, at.UserID)
+						require.Equal(t, model.CanAppend, at.Permission)
+						require.NotEmpty(t, at.Hash)
+					}).
+					Return(nil)
+
+				return store
+			},
+			claims: &auth.Claims{UserID: u.ID},
+			body:   `{"name":"ci","permission":32}`,
+			code:   http.StatusCreated,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+
+			handler := auth.CreateAccessToken(cfg, store)
+			req := httptest.NewRequest("POST", "/tokens", strings.NewReader(c.body))
+			req = req.WithContext(auth.ContextWithClaims(req.Context(), c.claims))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			if c.code == http.StatusCreated {
+				assert.Contains(t, res.Body.String(), `"token":"`+auth.AccessTokenPrefix)
+			}
+		})
+	}
+}
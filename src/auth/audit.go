@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// clientIP return address of client without port
+//
+// Require middleware.RealIP to get address of client behind proxy
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// auditFields return json fields of value, so hidden fields, like passhash,
+// are never recorded
+func auditFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	fields := map[string]interface{}{}
+	if err = json.Unmarshal(buf, &fields); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return fields, nil
+}
+
+// auditDiff return fields of before and after, which are different
+func auditDiff(before, after interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	bf, err := auditFields(before)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	af, err := auditFields(after)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	if bf == nil || af == nil {
+		return bf, af, nil
+	}
+	for k, v := range bf {
+		if av, ok := af[k]; ok && reflect.DeepEqual(v, av) {
+			delete(bf, k)
+			delete(af, k)
+		}
+	}
+
+	return bf, af, nil
+}
+
+// AuditAs record action of actor on target to audit log
+//
+// Before and after are states of target, only changed fields are recorded.
+// Failure is only logged, because action is already done
+func AuditAs(ctx context.Context, store AuditStore, r *http.Request, actor bson.ObjectId, action, target string, before, after interface{}) {
+	audit(ctx, store, r, &model.AuditEntry{ActorID: actor, Action: action, Target: target}, before, after)
+}
+
+// audit complete entry and record it
+func audit(ctx context.Context, store AuditStore, r *http.Request, e *model.AuditEntry, before, after interface{}) {
+	l := tracing.Logger(ctx)
+	e.ID = bson.NewObjectId()
+	e.IP = clientIP(r)
+	e.TraceID = tracing.TraceIDFromContext(ctx)
+	e.CreatedAt = time.Now()
+	var err error
+	e.Before, e.After, err = auditDiff(before, after)
+	if err == nil {
+		err = store.CreateAuditEntry(ctx, e)
+	}
+	if err != nil {
+		l.Error(err.Error(), errs.ZapStack(err))
+	}
+}
+
+// Audit record action of actor from claims in context on target to audit log
+func Audit(ctx context.Context, store AuditStore, r *http.Request, action, target string, before, after interface{}) {
+	e := &model.AuditEntry{Action: action, Target: target}
+	if c, ok := ClaimsFromContext(ctx); ok {
+		e.ActorID = c.UserID
+		e.ActorService = c.Service
+	}
+	audit(ctx, store, r, e, before, after)
+}
@@ -0,0 +1,65 @@
+package auth_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestAudit(t *testing.T) {
+	actor := bson.NewObjectId()
+	before := &model.User{ID: bson.NewObjectId(), Email: "old@email.com", Passhash: []byte("old"), Permission: model.CanRead}
+	after := *before
+	after.Email = "new@email.com"
+	after.Passhash = []byte("new")
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	store := NewMockStore(mockCtrl)
+	store.EXPECT().
+		CreateAuditEntry(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, e *model.AuditEntry) {
+			assert.Equal(t, actor, e.ActorID)
+			assert.False(t, e.ActorService)
+			assert.Equal(t, "user.update", e.Action)
+			assert.Equal(t, before.ID.Hex(), e.Target)
+			assert.Equal(t, "192.0.2.1", e.IP)
+			assert.Equal(t, map[string]interface{}{"email": "old@email.com"}, e.Before)
+			assert.Equal(t, map[string]interface{}{"email": "new@email.com"}, e.After)
+		}).
+		Return(nil)
+
+	req := httptest.NewRequest("PATCH", "/users/"+before.ID.Hex(), nil)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: actor})
+	auth.Audit(ctx, store, req, "user.update", before.ID.Hex(), before, &after)
+}
+
+func TestAuditService(t *testing.T) {
+	actor := bson.NewObjectId()
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	store := NewMockStore(mockCtrl)
+	store.EXPECT().
+		CreateAuditEntry(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, e *model.AuditEntry) {
+			assert.Equal(t, actor, e.ActorID)
+			assert.True(t, e.ActorService)
+		}).
+		Return(nil)
+
+	req := httptest.NewRequest("DELETE", "/groups/x", nil)
+	ctx := auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: actor, Service: true})
+	auth.Audit(ctx, store, req, "group.delete", "x", nil, nil)
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials is a cause of error for unknown email or wrong password
+const ErrInvalidCredentials errs.ConstantError = "invalid email or password"
+
+// Authenticator check email/password credentials
+type Authenticator interface {
+	// Authenticate return user with credentials
+	// or error caused by ErrInvalidCredentials
+	Authenticate(ctx context.Context, email, password string) (*model.User, error)
+}
+
+// NewAuthenticator return LDAP authenticator if cfg.LDAPAddr is set
+// and bcrypt authenticator otherwise
+func NewAuthenticator(cfg *config.Config, store Store) (Authenticator, error) {
+	if cfg.LDAPAddr == "" {
+		return NewBcryptAuthenticator(cfg, store), nil
+	}
+
+	a, err := NewLDAPAuthenticator(cfg, store)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return a, nil
+}
+
+// BcryptAuthenticator check password by user Passhash in store
+//
+// Hash with outdated cost is replaced on successful authentication
+type BcryptAuthenticator struct {
+	cfg   *config.Config
+	store Store
+}
+
+// NewBcryptAuthenticator return authenticator by password hash in store
+func NewBcryptAuthenticator(cfg *config.Config, store Store) *BcryptAuthenticator {
+	return &BcryptAuthenticator{cfg: cfg, store: store}
+}
+
+// Authenticate user by password hash
+func (a *BcryptAuthenticator) Authenticate(ctx context.Context, email, password string) (*model.User, error) {
+	u, err := a.store.GetUserByEmail(ctx, email)
+	if errors.Cause(err) == errs.ModelNotFound {
+		return nil, errors.WithMessage(ErrInvalidCredentials, err.Error())
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = bcrypt.CompareHashAndPassword(u.Passhash, []byte(password)); err != nil {
+		return nil, errors.WithMessage(ErrInvalidCredentials, err.Error())
+	}
+	rehashPassword(ctx, a.cfg, a.store, u.ID, u.Passhash, password)
+
+	return u, nil
+}
@@ -12,14 +12,66 @@ import (
 	"github.com/pkg/errors"
 )
 
+// AccessTokenTTL is a lifetime of access token
+const AccessTokenTTL = 15 * time.Minute
+
+// accessAudience is an audience of access token, other tokens signed
+// by the same keys, like invitations, have their own audiences
+const accessAudience = "access"
+
 type claimsCtxKey struct{}
 
 // Claims of authorization token
+//
+// Id of StandardClaims is used to revoke single token
+// and Version to revoke all tokens of user.
+// TokenID is set only for claims of personal access token.
+// Service claims are claims of service account credential with it's ID
+// as UserID and name as Email, they are never interactive.
+// SessionID is set for jwt issued on login and revoke it with session.
+// Unverified claims are restricted by config.UnverifiedPolicy.
+// Languages are binded languages of user and it's groups
 type Claims struct {
 	jwt.StandardClaims
-	UserID  bson.ObjectId `json:"userId"`
-	Email   string        `json:"email"`
-	IsAdmin bool          `json:"isAdmin"`
+	UserID     bson.ObjectId    `json:"userId"`
+	Email      string           `json:"email"`
+	IsAdmin    bool             `json:"isAdmin"`
+	Permission model.Permission `json:"permission"`
+	Languages  []string         `json:"langs,omitempty"`
+	Version    int              `json:"ver"`
+	Unverified bool             `json:"unverified,omitempty"`
+	SessionID  bson.ObjectId    `json:"sid,omitempty"`
+	TokenID    bson.ObjectId    `json:"-"`
+	Service    bool             `json:"-"`
+}
+
+// CanReadLanguage return true if claims allow to read translations on language
+//
+// CanReadAll allow every language and CanRead only binded ones.
+// Permission to edit language imply permission to read it
+func (c *Claims) CanReadLanguage(lang string) bool {
+	return c.Permission&model.CanReadAll != 0 ||
+		c.Permission&model.CanRead != 0 && c.isBinded(lang) ||
+		c.CanEditLanguage(lang)
+}
+
+// CanEditLanguage return true if claims allow to edit translations on language
+//
+// CanEditAll allow every language and CanEdit only binded ones
+func (c *Claims) CanEditLanguage(lang string) bool {
+	return c.Permission&model.CanEditAll != 0 ||
+		c.Permission&model.CanEdit != 0 && c.isBinded(lang)
+}
+
+// isBinded return true if language match one of binded languages
+func (c *Claims) isBinded(lang string) bool {
+	for _, b := range c.Languages {
+		if model.MatchLanguage(b, lang) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // ContextWithClaims store claims in context
@@ -34,21 +86,73 @@ func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
 	return c, ok
 }
 
-// CreateToken from user and sign it
-func CreateToken(ctx context.Context, secret string, u *model.User) (string, error) {
-	c := &Claims{
-		UserID:  u.ID,
-		Email:   u.Email,
-		IsAdmin: u.IsAdmin,
+// UserPermission is an effective permission and binded languages of user
+//
+// Permission is a union of user Permission and permissions of user roles
+// and groups, languages are a union of user and groups languages
+func UserPermission(ctx context.Context, store ClaimsStore, u *model.User) (model.Permission, []string, error) {
+	perm := u.Permission
+	langs := append([]string{}, u.Languages...)
+	if len(u.Roles) > 0 {
+		rl, err := store.GetRolesByNames(ctx, u.Roles)
+		if err != nil {
+			return 0, nil, errors.WithStack(err)
+		}
+		for _, r := range rl {
+			perm |= r.Permission
+		}
+	}
+	if len(u.Groups) > 0 {
+		gl, err := store.GetGroupsByIDs(ctx, u.Groups)
+		if err != nil {
+			return 0, nil, errors.WithStack(err)
+		}
+		seen := map[string]bool{}
+		for _, lang := range langs {
+			seen[lang] = true
+		}
+		for _, g := range gl {
+			perm |= g.Permission
+			for _, lang := range g.Languages {
+				if !seen[lang] {
+					seen[lang] = true
+					langs = append(langs, lang)
+				}
+			}
+		}
 	}
-	c.ExpiresAt = time.Now().AddDate(0, 0, 14).Unix()
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
 
-	st, err := t.SignedString([]byte(secret))
-	if err != nil {
+	return perm & model.CanEverything, langs, nil
+}
 
+// CreateToken from user with effective permission and languages and sign it
+func CreateToken(ctx context.Context, keys *Keyring, store ClaimsStore, u *model.User) (string, error) {
+	return createSessionToken(ctx, keys, store, u, "")
+}
+
+// createSessionToken create signed token of user session
+//
+// Token without session is not revoked by session removal
+func createSessionToken(ctx context.Context, keys *Keyring, store ClaimsStore, u *model.User, sid bson.ObjectId) (string, error) {
+	perm, langs, err := UserPermission(ctx, store, u)
+	if err != nil {
 		return "", errors.WithStack(err)
 	}
+	now := time.Now()
+	c := &Claims{
+		UserID:     u.ID,
+		Email:      u.Email,
+		IsAdmin:    u.IsAdmin,
+		Permission: perm,
+		Languages:  langs,
+		Version:    u.TokenVersion,
+		Unverified: u.EmailVerifiedAt == nil,
+		SessionID:  sid,
+	}
+	c.Id = bson.NewObjectId().Hex()
+	c.Audience = accessAudience
+	c.IssuedAt = now.Unix()
+	c.ExpiresAt = now.Add(AccessTokenTTL).Unix()
 
-	return st, nil
+	return keys.Sign(c)
 }
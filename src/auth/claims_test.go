@@ -0,0 +1,96 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestClaimsLanguage(t *testing.T) {
+	cases := []struct {
+		name       string
+		permission model.Permission
+		languages  []string
+		lang       string
+		read       bool
+		edit       bool
+	}{
+		{name: "No permission", languages: []string{"de"}, lang: "de"},
+		{name: "Read binded", permission: model.CanRead, languages: []string{"de"}, lang: "de", read: true},
+		{name: "Read not binded", permission: model.CanRead, languages: []string{"de"}, lang: "fr"},
+		{name: "Read all", permission: model.CanReadAll, lang: "fr", read: true},
+		{name: "Edit binded", permission: model.CanEdit, languages: []string{"pt"}, lang: "pt-BR", read: true, edit: true},
+		{name: "Edit not binded", permission: model.CanEdit | model.CanReadAll, languages: []string{"pt-BR"}, lang: "pt", read: true},
+		{name: "Edit all", permission: model.CanEditAll, lang: "zh-Hant", read: true, edit: true},
+		{name: "Prefix is not a language", permission: model.CanEdit, languages: []string{"pt"}, lang: "ptx"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			claims := &auth.Claims{Permission: c.permission, Languages: c.languages}
+			assert.Equal(t, c.read, claims.CanReadLanguage(c.lang))
+			assert.Equal(t, c.edit, claims.CanEditLanguage(c.lang))
+		})
+	}
+}
+
+func TestCreateTokenWithRoles(t *testing.T) {
+	u := &model.User{
+		ID:         bson.NewObjectId(),
+		Email:      "test@email.com",
+		Permission: model.CanAppend,
+		Roles:      []string{"translator", "removed"},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	store := NewMockStore(mockCtrl)
+	store.EXPECT().
+		GetRolesByNames(gomock.Any(), u.Roles).
+		Return([]*model.Role{{Name: "translator", Permission: model.CanRead | model.CanEdit}}, nil)
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	st, err := auth.CreateToken(context.Background(), keys, store, u)
+	require.NoError(t, err)
+	token, err := keys.Parse(st, &auth.Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, model.CanRead|model.CanEdit|model.CanAppend, token.Claims.(*auth.Claims).Permission)
+}
+
+func TestUserPermissionWithGroups(t *testing.T) {
+	u := &model.User{
+		ID:         bson.NewObjectId(),
+		Email:      "test@email.com",
+		Permission: model.CanRead,
+		Languages:  []string{"de"},
+		Groups:     []bson.ObjectId{bson.NewObjectId()},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	store := NewMockStore(mockCtrl)
+	store.EXPECT().
+		GetGroupsByIDs(gomock.Any(), u.Groups).
+		Return([]*model.Group{{ID: u.Groups[0], Permission: model.CanEdit, Languages: []string{"de", "fr"}}}, nil)
+
+	perm, langs, err := auth.UserPermission(context.Background(), store, u)
+	require.NoError(t, err)
+	assert.Equal(t, model.CanRead|model.CanEdit, perm)
+	assert.Equal(t, []string{"de", "fr"}, langs)
+	assert.Equal(t, []string{"de"}, u.Languages)
+}
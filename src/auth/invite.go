@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// InviteTokenTTL is a time while invitation is valid
+	InviteTokenTTL = 7 * 24 * time.Hour
+
+	inviteAudience = "invite"
+)
+
+// InviteClaims of invitation token
+type InviteClaims struct {
+	jwt.StandardClaims
+	Nonce string `json:"nonce"`
+}
+
+// Invite set new invitation nonce to user and return signed invitation token
+//
+// Previously issued invitation tokens of user become invalid
+// when the new nonce is stored
+func Invite(keys *Keyring, u *model.User) (string, error) {
+	nonce, _, err := NewRandomToken()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	now := time.Now()
+	u.InviteNonce = nonce
+	u.InvitedAt = &now
+
+	c := &InviteClaims{Nonce: nonce}
+	c.Audience = inviteAudience
+	c.Subject = u.ID.Hex()
+	c.ExpiresAt = now.Add(InviteTokenTTL).Unix()
+
+	return keys.Sign(c)
+}
+
+// parseInviteToken check signature, expiration and audience of invitation token
+func parseInviteToken(keys *Keyring, tokenString string) (*InviteClaims, error) {
+	token, err := keys.Parse(tokenString, &InviteClaims{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c := token.Claims.(*InviteClaims)
+	if !token.Valid || c.Audience != inviteAudience || !bson.IsObjectIdHex(c.Subject) || c.Nonce == "" {
+		return nil, errors.New("invalid invitation token")
+	}
+
+	return c, nil
+}
+
+// Accept invitation and set password of invited user
+func Accept(cfg *config.Config, keys *Keyring, store Store) http.HandlerFunc {
+	// Accept invitation and set password of invited user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Token    string `json:"token" valid:"required"`
+			Password string `json:"password" valid:"required"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		c, err := parseInviteToken(keys, rd.Token)
+		if err != nil {
+			l.Debug("bad invitation token", zap.Error(err))
+			http.Error(w, "invalid or expired invitation", http.StatusBadRequest)
+			return
+		}
+		u, err := store.GetUserByID(ctx, bson.ObjectIdHex(c.Subject))
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "invalid or expired invitation", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		passhash, err := HashPassword(cfg, u.Email, rd.Password)
+		if IsWeakPassword(err) {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			l.Error(err.Error())
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		err = store.AcceptUserInvite(ctx, u.ID, c.Nonce, passhash)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "invalid or expired invitation", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		// Invitation was sent to email, so it is verified by accept
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+		res, status, err := login(ctx, cfg, keys, store, r, u)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		AuditAs(ctx, store, r, u.ID, "invite.accept", u.ID.Hex(), nil, nil)
+		l.Sugar().Infof("user <%s> accepted invitation", u.Email)
+		render.Status(r, status)
+		render.JSON(w, r, res)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,114 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestAccept(t *testing.T) {
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+	u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com"}
+	token, err := auth.Invite(keys, u)
+	require.NoError(t, err)
+	nonce := u.InviteNonce
+
+	authToken, err := auth.CreateToken(context.Background(), keys, nil, u)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockStore
+		body  string
+		code  int
+	}{
+		{
+			name: "Bad token",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			body: `{"token":"token","password":"correct horse"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Auth token",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			body: `{"token":"` + authToken + `","password":"correct horse"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Already accepted",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+				store.EXPECT().
+					AcceptUserInvite(gomock.Any(), u.ID, nonce, gomock.Any()).
+					Return(errs.ModelNotFound)
+
+				return store
+			},
+			body: `{"token":"` + token + `","password":"correct horse"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Accepted",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+				store.EXPECT().
+					AcceptUserInvite(gomock.Any(), u.ID, nonce, gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			body: `{"token":"` + token + `","password":"correct horse"}`,
+			code: http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+
+			handler := auth.Accept(cfg, keys, store)
+			req := httptest.NewRequest("POST", "/accept", strings.NewReader(c.body))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/l10n-center/api/src/config"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// key to sign or verify tokens
+//
+// private is nil for keys used only to verify tokens signed before rotation
+type key struct {
+	id      string
+	method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+// Keyring sign tokens by current key and verify them by any known key
+//
+// Token header "kid" select verification key, so keys can be rotated
+// while tokens signed by previous key are still valid
+type Keyring struct {
+	signing *key
+	keys    map[string]*key
+}
+
+// NewKeyring load keys from cfg.KeysDir
+//
+// Every *.pem file in directory is a key with file name as id.
+// Private keys (RSA or Ed25519, PKCS#1 or PKCS#8) can sign tokens,
+// public keys (PKIX) only verify them. Tokens are signed by cfg.SigningKey
+// or by the only private key. Without cfg.KeysDir tokens are signed
+// by HS256 with cfg.Secret
+func NewKeyring(cfg *config.Config) (*Keyring, error) {
+	if cfg.KeysDir == "" {
+		k := &key{
+			method:  jwt.SigningMethodHS256,
+			private: []byte(cfg.Secret),
+			public:  []byte(cfg.Secret),
+		}
+
+		return &Keyring{signing: k, keys: map[string]*key{"": k}}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(cfg.KeysDir, "*.pem"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	kr := &Keyring{keys: map[string]*key{}}
+	for _, f := range files {
+		k, err := loadKey(f)
+		if err != nil {
+			return nil, errors.WithMessage(err, filepath.Base(f))
+		}
+		kr.keys[k.id] = k
+	}
+
+	if cfg.SigningKey != "" {
+		kr.signing = kr.keys[cfg.SigningKey]
+	} else {
+		for _, k := range kr.keys {
+			if k.private != nil {
+				if kr.signing != nil {
+					return nil, errors.New("many private keys found, set signing key")
+				}
+				kr.signing = k
+			}
+		}
+	}
+	if kr.signing == nil || kr.signing.private == nil {
+		return nil, errors.Errorf("private signing key not found in %q", cfg.KeysDir)
+	}
+
+	return kr, nil
+}
+
+// loadKey from pem file
+func loadKey(path string) (*key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	b, _ := pem.Decode(data)
+	if b == nil {
+		return nil, errors.New("no pem block")
+	}
+
+	k := &key{id: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+	var parsed interface{}
+	switch b.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(b.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(b.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(b.Bytes)
+	default:
+		err = errors.Errorf("unsupported pem block %q", b.Type)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	switch pk := parsed.(type) {
+	case *rsa.PrivateKey:
+		k.method, k.private, k.public = jwt.SigningMethodRS256, pk, &pk.PublicKey
+	case *rsa.PublicKey:
+		k.method, k.public = jwt.SigningMethodRS256, pk
+	case ed25519.PrivateKey:
+		k.method, k.private, k.public = SigningMethodEdDSA, pk, pk.Public()
+	case ed25519.PublicKey:
+		k.method, k.public = SigningMethodEdDSA, pk
+	default:
+		return nil, errors.Errorf("unsupported key type %T", parsed)
+	}
+
+	return k, nil
+}
+
+// Sign claims by current signing key
+func (kr *Keyring) Sign(c jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(kr.signing.method, c)
+	if kr.signing.id != "" {
+		t.Header["kid"] = kr.signing.id
+	}
+
+	st, err := t.SignedString(kr.signing.private)
+	if err != nil {
+
+		return "", errors.WithStack(err)
+	}
+
+	return st, nil
+}
+
+// Parse token and verify it by key from "kid" header
+func (kr *Keyring) Parse(tokenString string, c jwt.Claims) (*jwt.Token, error) {
+	token, err := jwt.ParseWithClaims(tokenString, c, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		k, ok := kr.keys[kid]
+		if !ok {
+
+			return nil, errors.Errorf("unknown key %q", kid)
+		}
+		if t.Method.Alg() != k.method.Alg() {
+
+			return nil, errors.Errorf("unexpected signing method %q", t.Method.Alg())
+		}
+
+		return k.public, nil
+	})
+
+	return token, errors.WithStack(err)
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// KeySet is a set of public keys in JWKS format
+type KeySet struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// JWKS return all public keys ordered by id
+//
+// Shared secret is never published, so set is empty for HS256
+func (kr *Keyring) JWKS() *KeySet {
+	set := &KeySet{Keys: []*JWK{}}
+	for _, k := range kr.keys {
+		jwk := &JWK{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+		switch pk := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pk.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pk.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pk)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+
+	return set
+}
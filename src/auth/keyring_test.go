@@ -0,0 +1,96 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+}
+
+func TestKeyring(t *testing.T) {
+	dir, err := ioutil.TempDir("", "l10n-center-keys")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "old.pem"), "PRIVATE KEY", der)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "new.pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	cfg := config.Default()
+	cfg.KeysDir = dir
+
+	_, err = auth.NewKeyring(cfg)
+	require.Error(t, err, "signing key must be selected from many private keys")
+
+	cfg.SigningKey = "old"
+	oldKeys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+	oldToken, err := oldKeys.Sign(&jwt.StandardClaims{Subject: "old"})
+	require.NoError(t, err)
+
+	// Rotate: sign by new key and keep only public part of old one
+	der, err = x509.MarshalPKIXPublicKey(edPub)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "old.pem"), "PUBLIC KEY", der)
+	cfg.SigningKey = "new"
+	newKeys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+	newToken, err := newKeys.Sign(&jwt.StandardClaims{Subject: "new"})
+	require.NoError(t, err)
+
+	token, err := newKeys.Parse(oldToken, &jwt.StandardClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "old", token.Header["kid"])
+	assert.Equal(t, "EdDSA", token.Method.Alg())
+
+	token, err = newKeys.Parse(newToken, &jwt.StandardClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", token.Header["kid"])
+	assert.Equal(t, "RS256", token.Method.Alg())
+
+	_, err = oldKeys.Parse(newToken, &jwt.StandardClaims{})
+	require.NoError(t, err)
+
+	cfg.SigningKey = "old"
+	_, err = auth.NewKeyring(cfg)
+	require.Error(t, err, "public key can't sign")
+
+	set := newKeys.JWKS()
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "new", set.Keys[0].Kid)
+	assert.Equal(t, "RSA", set.Keys[0].Kty)
+	assert.Equal(t, "AQAB", set.Keys[0].E)
+	assert.Equal(t, "old", set.Keys[1].Kid)
+	assert.Equal(t, "OKP", set.Keys[1].Kty)
+	assert.Equal(t, "Ed25519", set.Keys[1].Crv)
+
+	hsKeys, err := auth.NewKeyring(config.Default())
+	require.NoError(t, err)
+	assert.Empty(t, hsKeys.JWKS().Keys)
+	_, err = hsKeys.Parse(newToken, &jwt.StandardClaims{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/ldap.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ldapTimeLimit of user search in seconds
+const ldapTimeLimit = 10
+
+// LDAPAuthenticator check password by bind to LDAP server
+//
+// User entry is searched by email with service account, then bound
+// with password. Group membership of entry define IsAdmin and Permission,
+// which are synced to store, unknown users are created on first login
+type LDAPAuthenticator struct {
+	cfg    *config.Config
+	store  Store
+	admin  string
+	groups map[string]model.Permission
+}
+
+// NewLDAPAuthenticator parse group mapping from cfg
+func NewLDAPAuthenticator(cfg *config.Config, store Store) (*LDAPAuthenticator, error) {
+	a := &LDAPAuthenticator{
+		cfg:    cfg,
+		store:  store,
+		admin:  strings.ToLower(cfg.LDAPAdminGroup),
+		groups: map[string]model.Permission{},
+	}
+	for _, g := range strings.Split(cfg.LDAPGroups, ";") {
+		if strings.TrimSpace(g) == "" {
+			continue
+		}
+		i := strings.LastIndex(g, ":")
+		if i < 0 {
+			return nil, errors.Errorf("ldap group %q: permission required", g)
+		}
+		p, err := strconv.ParseInt(g[i+1:], 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "ldap group %q", g)
+		}
+		a.groups[strings.ToLower(strings.TrimSpace(g[:i]))] |= model.Permission(p) & model.CanEverything
+	}
+
+	return a, nil
+}
+
+// dial LDAP server
+func (a *LDAPAuthenticator) dial() (*ldap.Conn, error) {
+	if a.cfg.LDAPTLS {
+		host, _, _ := net.SplitHostPort(a.cfg.LDAPAddr)
+
+		return ldap.DialTLS("tcp", a.cfg.LDAPAddr, &tls.Config{ServerName: host})
+	}
+
+	return ldap.Dial("tcp", a.cfg.LDAPAddr)
+}
+
+// Authenticate user by bind to LDAP server
+func (a *LDAPAuthenticator) Authenticate(ctx context.Context, email, password string) (*model.User, error) {
+	// Empty password is an unauthenticated bind, which always succeed
+	if password == "" {
+		return nil, errors.WithStack(ErrInvalidCredentials)
+	}
+
+	sp, _ := opentracing.StartSpanFromContext(ctx, "ldap:Authenticate")
+	conn, err := a.dial()
+	if err != nil {
+		sp.Finish()
+		return nil, errors.WithStack(err)
+	}
+	entry, err := a.bind(conn, email, password)
+	conn.Close()
+	sp.Finish()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	isAdmin := false
+	var perm model.Permission
+	for _, g := range entry.GetAttributeValues(a.cfg.LDAPGroupAttr) {
+		g = strings.ToLower(g)
+		if a.admin != "" && g == a.admin {
+			isAdmin = true
+		}
+		perm |= a.groups[g]
+	}
+
+	return a.sync(ctx, email, isAdmin, perm)
+}
+
+// bind as user found by email and return it's entry
+func (a *LDAPAuthenticator) bind(conn *ldap.Conn, email, password string) (*ldap.Entry, error) {
+	if a.cfg.LDAPBindDN != "" {
+		if err := conn.Bind(a.cfg.LDAPBindDN, a.cfg.LDAPBindPassword); err != nil {
+			return nil, errors.WithMessage(err, "service bind")
+		}
+	}
+	res, err := conn.Search(ldap.NewSearchRequest(
+		a.cfg.LDAPBaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, ldapTimeLimit, false,
+		fmt.Sprintf(a.cfg.LDAPUserFilter, ldap.EscapeFilter(email)),
+		[]string{"dn", a.cfg.LDAPGroupAttr},
+		nil,
+	))
+	if err != nil {
+		return nil, errors.WithMessage(err, "search")
+	}
+	if len(res.Entries) != 1 {
+		return nil, errors.WithMessage(ErrInvalidCredentials, fmt.Sprintf("%d entries found", len(res.Entries)))
+	}
+	entry := res.Entries[0]
+	err = conn.Bind(entry.DN, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return nil, errors.WithMessage(ErrInvalidCredentials, err.Error())
+	} else if err != nil {
+		return nil, errors.WithMessage(err, "bind")
+	}
+
+	return entry, nil
+}
+
+// sync user in store with LDAP groups
+func (a *LDAPAuthenticator) sync(ctx context.Context, email string, isAdmin bool, perm model.Permission) (*model.User, error) {
+	u, err := a.store.GetUserByEmail(ctx, email)
+	if errors.Cause(err) == errs.ModelNotFound {
+		deleted, err := a.store.HasDeletedUser(ctx, email)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if deleted {
+			// Deleted user is not created again by directory until it is purged
+			return nil, errors.WithMessage(ErrInvalidCredentials, "user is deleted")
+		}
+		u = &model.User{
+			ID:         bson.NewObjectId(),
+			Email:      email,
+			IsAdmin:    isAdmin,
+			Permission: perm,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+		// Email is verified by directory
+		u.EmailVerifiedAt = &u.CreatedAt
+
+		if err = a.store.CreateUser(ctx, u); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return u, nil
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if u.IsAdmin != isAdmin || u.Permission != perm {
+		u.IsAdmin = isAdmin
+		u.Permission = perm
+		u.UpdatedAt = time.Now()
+		err = a.store.UpdateUser(ctx, u.ID, &model.UserChange{IsAdmin: &u.IsAdmin, Permission: &u.Permission})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return u, nil
+}
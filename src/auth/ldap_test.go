@@ -0,0 +1,280 @@
+package auth_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/asn1-ber.v1"
+	"gopkg.in/ldap.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ldapEntry of in-process LDAP stub
+type ldapEntry struct {
+	password string
+	attrs    map[string][]string
+}
+
+// ldapStub is an in-process LDAP server, which support only simple bind
+// and search by equality filter
+type ldapStub struct {
+	net.Listener
+	entries map[string]*ldapEntry
+}
+
+func newLDAPStub(t *testing.T, entries map[string]*ldapEntry) *ldapStub {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &ldapStub{Listener: ln, entries: entries}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func ldapResult(id int64, op ber.Tag, code int, msg string) *ber.Packet {
+	p := ber.NewSequence("response")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "id"))
+	res := ber.Encode(ber.ClassApplication, ber.TypeConstructed, op, nil, "result")
+	res.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "code"))
+	res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matched"))
+	res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, msg, "message"))
+	p.AppendChild(res)
+
+	return p
+}
+
+func (s *ldapStub) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		p, err := ber.ReadPacket(conn)
+		if err != nil || len(p.Children) < 2 {
+			return
+		}
+		id := p.Children[0].Value.(int64)
+		op := p.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := op.Children[1].Value.(string)
+			e, ok := s.entries[dn]
+			code := ldap.LDAPResultSuccess
+			if !ok || e.password != op.Children[2].Data.String() {
+				code = ldap.LDAPResultInvalidCredentials
+			}
+			conn.Write(ldapResult(id, ldap.ApplicationBindResponse, code, "").Bytes())
+		case ldap.ApplicationSearchRequest:
+			filter, _ := ldap.DecompileFilter(op.Children[6])
+			kv := strings.SplitN(strings.Trim(filter, "()"), "=", 2)
+			for dn, e := range s.entries {
+				if len(kv) != 2 || !strings.Contains(strings.Join(e.attrs[kv[0]], "\n"), kv[1]) {
+					continue
+				}
+				res := ber.NewSequence("response")
+				res.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "id"))
+				entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "entry")
+				entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "dn"))
+				attrs := ber.NewSequence("attributes")
+				for name, values := range e.attrs {
+					attr := ber.NewSequence("attribute")
+					attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "name"))
+					set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "values")
+					for _, v := range values {
+						set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "value"))
+					}
+					attr.AppendChild(set)
+					attrs.AppendChild(attr)
+				}
+				entry.AppendChild(attrs)
+				res.AppendChild(entry)
+				conn.Write(res.Bytes())
+			}
+			conn.Write(ldapResult(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess, "").Bytes())
+		default:
+			return
+		}
+	}
+}
+
+func TestLDAPAuthenticator(t *testing.T) {
+	const (
+		userDN    = "uid=test,ou=people,dc=example,dc=com"
+		adminsDN  = "cn=admins,ou=groups,dc=example,dc=com"
+		editorsDN = "cn=editors,ou=groups,dc=example,dc=com"
+	)
+	stub := newLDAPStub(t, map[string]*ldapEntry{
+		"cn=service,dc=example,dc=com": {password: "service"},
+		userDN: {
+			password: "secret",
+			attrs: map[string][]string{
+				"mail":     {"test@email.com"},
+				"memberOf": {adminsDN, strings.ToUpper(editorsDN)},
+			},
+		},
+	})
+
+	defer stub.Close()
+
+	u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com", Permission: model.CanRead}
+
+	cases := []struct {
+		name     string
+		store    func(*gomock.Controller) *MockStore
+		email    string
+		password string
+		err      error
+	}{
+		{
+			name: "Unknown email",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			email:    "unknown@email.com",
+			password: "secret",
+			err:      auth.ErrInvalidCredentials,
+		},
+		{
+			name: "Wrong password",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			email:    "test@email.com",
+			password: "wrong",
+			err:      auth.ErrInvalidCredentials,
+		},
+		{
+			name: "Empty password",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			email: "test@email.com",
+			err:   auth.ErrInvalidCredentials,
+		},
+		{
+			name: "New user",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "test@email.com").
+					Return(nil, errs.ModelNotFound)
+				store.EXPECT().
+					HasDeletedUser(gomock.Any(), "test@email.com").
+					Return(false, nil)
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, nu *model.User) {
+						require.True(t, nu.IsAdmin)
+						require.Equal(t, model.CanRead|model.CanEdit, nu.Permission)
+					}).
+					Return(nil)
+
+				return store
+			},
+			email:    "test@email.com",
+			password: "secret",
+		},
+		{
+			name: "Deleted user",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "test@email.com").
+					Return(nil, errs.ModelNotFound)
+				store.EXPECT().
+					HasDeletedUser(gomock.Any(), "test@email.com").
+					Return(true, nil)
+
+				return store
+			},
+			email:    "test@email.com",
+			password: "secret",
+			err:      auth.ErrInvalidCredentials,
+		},
+		{
+			name: "Groups changed",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "test@email.com").
+					Return(u, nil)
+				store.EXPECT().
+					UpdateUser(gomock.Any(), u.ID, gomock.Any()).
+					Do(func(_ context.Context, _ bson.ObjectId, ch *model.UserChange) {
+						require.True(t, *ch.IsAdmin)
+						require.Equal(t, model.CanRead|model.CanEdit, *ch.Permission)
+						require.Nil(t, ch.Email)
+						require.Nil(t, ch.Passhash)
+						require.Nil(t, ch.Roles)
+					}).
+					Return(nil)
+
+				return store
+			},
+			email:    "test@email.com",
+			password: "secret",
+		},
+		{
+			name: "Groups not changed",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "test@email.com").
+					Return(&model.User{
+						ID:         u.ID,
+						Email:      u.Email,
+						IsAdmin:    true,
+						Permission: model.CanRead | model.CanEdit,
+					}, nil)
+
+				return store
+			},
+			email:    "test@email.com",
+			password: "secret",
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	cfg.LDAPAddr = stub.Addr().String()
+	cfg.LDAPBindDN = "cn=service,dc=example,dc=com"
+	cfg.LDAPBindPassword = "service"
+	cfg.LDAPBaseDN = "dc=example,dc=com"
+	cfg.LDAPAdminGroup = adminsDN
+	cfg.LDAPGroups = "cn=readers,ou=groups,dc=example,dc=com:2;" + editorsDN + ":10"
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			authn, err := auth.NewAuthenticator(cfg, c.store(mockCtrl))
+			require.NoError(t, err)
+
+			u, err := authn.Authenticate(context.Background(), c.email, c.password)
+			if c.err != nil {
+				require.Equal(t, c.err, errors.Cause(err), "%v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.email, u.Email)
+		})
+	}
+}
@@ -0,0 +1,445 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// OIDCSessionTTL is a time to complete single sign-on
+	OIDCSessionTTL = 10 * time.Minute
+
+	oidcAudience = "oidc"
+)
+
+// OIDC is an OpenID Connect provider from cfg.OIDCIssuer
+//
+// Provider metadata is discovered on first use and cached
+type OIDC struct {
+	cfg    *config.Config
+	client *http.Client
+
+	mu   sync.Mutex
+	meta *oidcMeta
+}
+
+// oidcMeta is a part of provider metadata used by authorization code flow
+type oidcMeta struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewOIDC return provider configured by cfg or nil if single sign-on is disabled
+func NewOIDC(cfg *config.Config) *OIDC {
+	if cfg.OIDCIssuer == "" {
+		return nil
+	}
+
+	return &OIDC{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// getJSON request url and unmarshal response to v
+func (p *OIDC) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return p.do(ctx, req, v)
+}
+
+// do request and unmarshal response to v
+func (p *OIDC) do(ctx context.Context, req *http.Request, v interface{}) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "oidc:"+req.URL.Path)
+
+	defer sp.Finish()
+
+	req.Header.Set("Accept", "application/json")
+	res, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("%s %s: %s", req.Method, req.URL, res.Status)
+	}
+
+	return errors.WithStack(json.NewDecoder(res.Body).Decode(v))
+}
+
+// discover provider metadata
+func (p *OIDC) discover(ctx context.Context) (*oidcMeta, error) {
+	p.mu.Lock()
+
+	defer p.mu.Unlock()
+
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	meta := &oidcMeta{}
+	err := p.getJSON(ctx, strings.TrimSuffix(p.cfg.OIDCIssuer, "/")+"/.well-known/openid-configuration", meta)
+	if err != nil {
+		return nil, errors.WithMessage(err, "discovery")
+	}
+	if meta.Issuer != p.cfg.OIDCIssuer {
+		return nil, errors.Errorf("discovery: issuer %q mismatch", meta.Issuer)
+	}
+	p.meta = meta
+
+	return meta, nil
+}
+
+// AuthURL return url of provider login page
+//
+// PKCE challenge is derived from verifier by S256 method
+func (p *OIDC) AuthURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("scope", "openid email")
+	q.Set("client_id", p.cfg.OIDCClientID)
+	q.Set("redirect_uri", p.cfg.OIDCRedirectURL)
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(HashToken(verifier)))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange authorization code to verified claims of id token
+func (p *OIDC) Exchange(ctx context.Context, code, verifier, nonce string) (*IDClaims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.OIDCRedirectURL)
+	form.Set("client_id", p.cfg.OIDCClientID)
+	form.Set("code_verifier", verifier)
+	req, err := http.NewRequest(http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.cfg.OIDCClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.OIDCClientID), url.QueryEscape(p.cfg.OIDCClientSecret))
+	}
+	res := &struct {
+		IDToken string `json:"id_token"`
+	}{}
+	if err = p.do(ctx, req, res); err != nil {
+		return nil, errors.WithMessage(err, "token")
+	}
+	if res.IDToken == "" {
+		return nil, errors.New("token: no id token in response")
+	}
+
+	return p.verify(ctx, meta, res.IDToken, nonce)
+}
+
+// audience is a string or an array of strings
+type audience []string
+
+// UnmarshalJSON accept both forms of "aud" claim
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var l []string
+	if err := json.Unmarshal(data, &l); err != nil {
+		return errors.WithStack(err)
+	}
+	*a = l
+
+	return nil
+}
+
+// contains check client id is in audience
+func (a audience) contains(clientID string) bool {
+	for _, id := range a {
+		if id == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IDClaims of OpenID Connect id token
+type IDClaims struct {
+	jwt.StandardClaims
+	Audience      audience `json:"aud"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified *bool    `json:"email_verified"`
+}
+
+// verify id token by provider keys and check it is issued for us
+func (p *OIDC) verify(ctx context.Context, meta *oidcMeta, idToken, nonce string) (*IDClaims, error) {
+	set := &KeySet{}
+	if err := p.getJSON(ctx, meta.JWKSURI, set); err != nil {
+		return nil, errors.WithMessage(err, "jwks")
+	}
+
+	c := &IDClaims{}
+	_, err := jwt.ParseWithClaims(idToken, c, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		for _, jwk := range set.Keys {
+			if jwk.Kid != kid && kid != "" {
+				continue
+			}
+			switch {
+			case jwk.Kty == "RSA" && t.Method.Alg() == jwt.SigningMethodRS256.Alg():
+				return jwk.rsaPublicKey()
+			case jwk.Kty == "OKP" && jwk.Crv == "Ed25519" && t.Method.Alg() == SigningMethodEdDSA.Alg():
+				return jwk.ed25519PublicKey()
+			}
+		}
+
+		return nil, errors.Errorf("no %s key %q", t.Method.Alg(), kid)
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if c.Issuer != meta.Issuer {
+		return nil, errors.Errorf("unexpected issuer %q", c.Issuer)
+	}
+	if !c.Audience.contains(p.cfg.OIDCClientID) {
+		return nil, errors.Errorf("unexpected audience %q", c.Audience)
+	}
+	if c.Nonce != nonce {
+		return nil, errors.New("nonce mismatch")
+	}
+	if c.Email == "" || c.EmailVerified != nil && !*c.EmailVerified {
+		return nil, errors.New("no verified email")
+	}
+
+	return c, nil
+}
+
+// rsaPublicKey decode RSA key from JWK
+func (jwk *JWK) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
+
+// ed25519PublicKey decode Ed25519 key from JWK
+func (jwk *JWK) ed25519PublicKey() (ed25519.PublicKey, error) {
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(x) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 key size")
+	}
+
+	return ed25519.PublicKey(x), nil
+}
+
+// OIDCClaims of single sign-on session, kept by client between start and callback
+type OIDCClaims struct {
+	jwt.StandardClaims
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// parseOIDCSession check signature, expiration and audience of session token
+func parseOIDCSession(keys *Keyring, tokenString string) (*OIDCClaims, error) {
+	token, err := keys.Parse(tokenString, &OIDCClaims{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c := token.Claims.(*OIDCClaims)
+	if !token.Valid || c.Audience != oidcAudience || c.State == "" || c.Verifier == "" {
+		return nil, errors.New("invalid sso session")
+	}
+
+	return c, nil
+}
+
+// OIDCStart begin single sign-on
+//
+// Response has provider login url and signed session, which client
+// must send to callback together with code and state returned by provider
+func OIDCStart(_ *config.Config, keys *Keyring, sso *OIDC) http.HandlerFunc {
+	// Begin single sign-on
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		c := &OIDCClaims{}
+		var err error
+		for _, v := range []*string{&c.State, &c.Nonce, &c.Verifier} {
+			if *v, _, err = NewRandomToken(); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		c.Audience = oidcAudience
+		c.ExpiresAt = time.Now().Add(OIDCSessionTTL).Unix()
+
+		u, err := sso.AuthURL(ctx, c.State, c.Nonce, c.Verifier)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, "sso provider unavailable", http.StatusBadGateway)
+			return
+		}
+		session, err := keys.Sign(c)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, map[string]string{"url": u, "session": session})
+	}
+	return http.HandlerFunc(fn)
+}
+
+// OIDCCallback complete single sign-on and login user with email of id token
+//
+// Unknown users are created with cfg.OIDCPermission if cfg.OIDCCreateUsers.
+// Users with enabled or mandatory TOTP get challenge as after login
+func OIDCCallback(cfg *config.Config, keys *Keyring, store Store, sso *OIDC) http.HandlerFunc {
+	// Complete single sign-on
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Code    string `json:"code" valid:"required"`
+			State   string `json:"state" valid:"required"`
+			Session string `json:"session" valid:"required"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		session, err := parseOIDCSession(keys, rd.Session)
+		if err != nil || session.State != rd.State {
+			l.Debug("invalid sso session", zap.Error(err))
+			http.Error(w, "invalid sso session", http.StatusBadRequest)
+			return
+		}
+		ic, err := sso.Exchange(ctx, rd.Code, session.Verifier, session.Nonce)
+		if err != nil {
+			l.Warn(err.Error(), zap.Error(err))
+			http.Error(w, "sso failed", http.StatusUnauthorized)
+			return
+		}
+
+		u, err := store.GetUserByEmail(ctx, ic.Email)
+		if errors.Cause(err) == errs.ModelNotFound && cfg.OIDCCreateUsers {
+			var deleted bool
+			deleted, err = store.HasDeletedUser(ctx, ic.Email)
+			if err == nil && deleted {
+				// Deleted user is not created again by provider until it is purged
+				err = errors.WithMessage(errs.ModelNotFound, "user is deleted")
+			} else if err == nil {
+				u = &model.User{
+					ID:         bson.NewObjectId(),
+					Email:      ic.Email,
+					Permission: model.Permission(cfg.OIDCPermission) & model.CanEverything,
+					CreatedAt:  time.Now(),
+					UpdatedAt:  time.Now(),
+				}
+				// Email is verified by provider
+				u.EmailVerifiedAt = &u.CreatedAt
+				err = store.CreateUser(ctx, u)
+				if err == nil {
+					AuditAs(ctx, store, r, u.ID, "user.create.sso", u.ID.Hex(), nil, u)
+					l.Sugar().Infof("user created by sso with email <%s>", u.Email)
+				}
+			}
+		}
+		// ModelExists means that user with email is created concurrently
+		if errors.Cause(err) == errs.ModelNotFound || errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusForbidden)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		res, status, err := login(ctx, cfg, keys, store, r, u)
+		if errors.Cause(err) == ErrEmailNotVerified {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, ErrEmailNotVerified.Error(), http.StatusForbidden)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if status == http.StatusOK {
+			AuditAs(ctx, store, r, u.ID, "login.sso", u.ID.Hex(), nil, nil)
+		}
+		l.Sugar().Infof("user <%s> is logined by sso", u.Email)
+		render.Status(r, status)
+		render.JSON(w, r, res)
+	}
+	return http.HandlerFunc(fn)
+}
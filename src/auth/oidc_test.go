@@ -0,0 +1,259 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// fakeIssuer is an in-process OpenID Connect provider
+type fakeIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]url.Values
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	fi := &fakeIssuer{key: key, codes: map[string]url.Values{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 fi.URL,
+			"authorization_endpoint": fi.URL + "/authorize",
+			"token_endpoint":         fi.URL + "/token",
+			"jwks_uri":               fi.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&auth.KeySet{Keys: []*auth.JWK{{
+			Kty: "RSA",
+			Kid: "fake",
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		fi.mu.Lock()
+		q, ok := fi.codes[r.FormValue("code")]
+		delete(fi.codes, r.FormValue("code"))
+		fi.mu.Unlock()
+
+		h := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(h[:]) != q.Get("code_challenge") {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   fi.URL,
+			"sub":   "subject",
+			"aud":   []string{q.Get("client_id")},
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": q.Get("nonce"),
+			"email": q.Get("email"),
+		})
+		token.Header["kid"] = "fake"
+		st, err := token.SignedString(key)
+		require.NoError(t, err)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": st})
+	})
+	fi.Server = httptest.NewServer(mux)
+
+	return fi
+}
+
+// login simulate user login at provider and return authorization code
+func (fi *fakeIssuer) login(t *testing.T, authURL, email string) string {
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	require.Equal(t, fi.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	q := u.Query()
+	require.Equal(t, "S256", q.Get("code_challenge_method"))
+	q.Set("email", email)
+
+	code := bson.NewObjectId().Hex()
+	fi.mu.Lock()
+	fi.codes[code] = q
+	fi.mu.Unlock()
+
+	return code
+}
+
+func TestOIDC(t *testing.T) {
+	fi := newFakeIssuer(t)
+
+	defer fi.Close()
+
+	u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com"}
+
+	cases := []struct {
+		name   string
+		store  func(*gomock.Controller) *MockStore
+		create bool
+		email  string
+		state  string
+		code   int
+	}{
+		{
+			name: "State mismatch",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			email: u.Email,
+			state: "state",
+			code:  http.StatusBadRequest,
+		},
+		{
+			name: "Existing user",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), u.Email).
+					Return(u, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			email: u.Email,
+			code:  http.StatusOK,
+		},
+		{
+			name: "Unknown user",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "new@email.com").
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			email: "new@email.com",
+			code:  http.StatusForbidden,
+		},
+		{
+			name: "Deleted user",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "new@email.com").
+					Return(nil, errs.ModelNotFound)
+				store.EXPECT().
+					HasDeletedUser(gomock.Any(), "new@email.com").
+					Return(true, nil)
+
+				return store
+			},
+			create: true,
+			email:  "new@email.com",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Created user",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "new@email.com").
+					Return(nil, errs.ModelNotFound)
+				store.EXPECT().
+					HasDeletedUser(gomock.Any(), "new@email.com").
+					Return(false, nil)
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Do(func(_ interface{}, nu *model.User) {
+						require.Equal(t, "new@email.com", nu.Email)
+						require.Equal(t, model.CanRead, nu.Permission)
+						require.False(t, nu.IsAdmin)
+					}).
+					Return(nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			create: true,
+			email:  "new@email.com",
+			code:   http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.OIDCIssuer = fi.URL
+			cfg.OIDCClientID = "client"
+			cfg.OIDCRedirectURL = "http://localhost/sso"
+			cfg.OIDCCreateUsers = c.create
+			cfg.OIDCPermission = int(model.CanRead)
+			keys, err := auth.NewKeyring(cfg)
+			require.NoError(t, err)
+			sso := auth.NewOIDC(cfg)
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+
+			req := httptest.NewRequest("GET", "/oidc/start", nil)
+			res := httptest.NewRecorder()
+			auth.OIDCStart(cfg, keys, sso)(res, req)
+			require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+			start := map[string]string{}
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&start))
+
+			code := fi.login(t, start["url"], c.email)
+			authURL, _ := url.Parse(start["url"])
+			state := authURL.Query().Get("state")
+			if c.state != "" {
+				state = c.state
+			}
+			body, _ := json.Marshal(map[string]string{"code": code, "state": state, "session": start["session"]})
+
+			req = httptest.NewRequest("POST", "/oidc/callback", strings.NewReader(string(body)))
+			res = httptest.NewRecorder()
+			auth.OIDCCallback(cfg, keys, store, sso)(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			if c.code == http.StatusOK {
+				require.Contains(t, res.Body.String(), `"refreshToken"`)
+			}
+		})
+	}
+}
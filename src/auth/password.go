@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// PasswordError describe violation of password policy
+type PasswordError string
+
+func (e PasswordError) Error() string {
+	return string(e)
+}
+
+// IsWeakPassword return true if error is caused by password policy
+func IsWeakPassword(err error) bool {
+	_, ok := errors.Cause(err).(PasswordError)
+
+	return ok
+}
+
+// bcryptCost from cfg, bcrypt use default cost for too low one
+func bcryptCost(cfg *config.Config) int {
+	if cfg.BcryptCost < bcrypt.MinCost {
+		return bcrypt.DefaultCost
+	}
+
+	return cfg.BcryptCost
+}
+
+// ValidatePassword of user with email by policy
+//
+// Password must be at least cfg.PasswordMinLength characters, must not be
+// in list of common passwords and must not contain email or it's local part
+func ValidatePassword(cfg *config.Config, email, password string) error {
+	if utf8.RuneCountInString(password) < cfg.PasswordMinLength {
+		return errors.WithStack(PasswordError(fmt.Sprintf("password must have at least %d characters", cfg.PasswordMinLength)))
+	}
+	lp := strings.ToLower(password)
+	if _, ok := commonPasswords[lp]; ok {
+		return errors.WithStack(PasswordError("password is too common"))
+	}
+	email = strings.ToLower(email)
+	local := email
+	if i := strings.LastIndex(email, "@"); i >= 0 {
+		local = email[:i]
+	}
+	if email != "" && strings.Contains(lp, email) || len(local) >= 3 && strings.Contains(lp, local) {
+		return errors.WithStack(PasswordError("password must not contain email"))
+	}
+
+	return nil
+}
+
+// HashPassword of user with email, if password is valid by policy
+func HashPassword(cfg *config.Config, email, password string) ([]byte, error) {
+	if err := ValidatePassword(cfg, email, password); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	passhash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost(cfg))
+
+	return passhash, errors.WithStack(err)
+}
+
+// rehashPassword store new hash of password if stored hash has outdated cost
+//
+// Failure is only logged, because user is already authenticated
+func rehashPassword(ctx context.Context, cfg *config.Config, store Store, id bson.ObjectId, passhash []byte, password string) {
+	cost, err := bcrypt.Cost(passhash)
+	if err == nil && cost == bcryptCost(cfg) {
+		return
+	}
+	passhash, err = bcrypt.GenerateFromPassword([]byte(password), bcryptCost(cfg))
+	if err == nil {
+		err = store.UpdateUserPasshash(ctx, id, passhash)
+	}
+	if err != nil {
+		tracing.Logger(ctx).Error(err.Error(), errs.ZapStack(err))
+		return
+	}
+	tracing.Logger(ctx).Sugar().Infof("password of user <%s> rehashed with cost %d", id.Hex(), bcryptCost(cfg))
+}
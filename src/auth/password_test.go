@@ -0,0 +1,87 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestValidatePassword(t *testing.T) {
+	cases := []struct {
+		name     string
+		password string
+		weak     bool
+	}{
+		{name: "Short", password: "x7#kq", weak: true},
+		{name: "Common", password: "password1", weak: true},
+		{name: "Common upper case", password: "PASSWORD1", weak: true},
+		{name: "Email", password: "my-test@email.com", weak: true},
+		{name: "Local part", password: "Test-1984!", weak: true},
+		{name: "Strong", password: "correct horse"},
+	}
+
+	cfg := config.Default()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := auth.ValidatePassword(cfg, "test@email.com", c.password)
+			assert.Equal(t, c.weak, auth.IsWeakPassword(err), "%v", err)
+			if !c.weak {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	cfg := config.Default()
+	cfg.BcryptCost = bcrypt.MinCost + 1
+
+	passhash, err := auth.HashPassword(cfg, "test@email.com", "correct horse")
+	require.NoError(t, err)
+	cost, err := bcrypt.Cost(passhash)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.BcryptCost, cost)
+
+	_, err = auth.HashPassword(cfg, "test@email.com", "secret")
+	assert.True(t, auth.IsWeakPassword(err))
+}
+
+func TestRehashPassword(t *testing.T) {
+	cfg := config.Default()
+	cfg.BcryptCost = bcrypt.MinCost + 1
+
+	passhash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com", Passhash: passhash}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	store := NewMockStore(mockCtrl)
+	store.EXPECT().
+		GetUserByEmail(gomock.Any(), u.Email).
+		Return(u, nil)
+	store.EXPECT().
+		UpdateUserPasshash(gomock.Any(), u.ID, gomock.Any()).
+		Do(func(_ context.Context, _ bson.ObjectId, passhash []byte) {
+			cost, err := bcrypt.Cost(passhash)
+			require.NoError(t, err)
+			assert.Equal(t, cfg.BcryptCost, cost)
+			assert.NoError(t, bcrypt.CompareHashAndPassword(passhash, []byte("secret")))
+		}).
+		Return(nil)
+
+	_, err = auth.NewBcryptAuthenticator(cfg, store).Authenticate(context.Background(), u.Email, "secret")
+	require.NoError(t, err)
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/mail"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// resetTokenTTL is a time while password reset token is valid
+const resetTokenTTL = time.Hour
+
+// NewRandomToken return url safe random string and sha256 hash of it to store
+func NewRandomToken() (string, []byte, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	return token, HashToken(token), nil
+}
+
+// HashToken to store it or search by it
+func HashToken(token string) []byte {
+	h := sha256.Sum256([]byte(token))
+
+	return h[:]
+}
+
+// Forgot send password reset token to user email
+func Forgot(_ *config.Config, store Store, mailer mail.Mailer) http.HandlerFunc {
+	// Send password reset token to user email
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Email string `json:"email" valid:"email,required"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		u, err := store.GetUserByEmail(ctx, rd.Email)
+		if errors.Cause(err) == errs.ModelNotFound {
+			// Same answer as for existing user to not disclose registered emails
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "reset token sent", http.StatusAccepted)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		token, hash, err := NewRandomToken()
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if err = store.SetUserResetToken(ctx, u.ID, hash, time.Now().Add(resetTokenTTL)); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		err = mailer.Send(ctx, &mail.Message{
+			To:      u.Email,
+			Subject: "Password reset",
+			Body: fmt.Sprintf(
+				"Use this token to reset your password: %s\n\nIt expires in %s.",
+				token,
+				resetTokenTTL,
+			),
+		})
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		l.Sugar().Infof("reset token sent to <%s>", u.Email)
+		http.Error(w, "reset token sent", http.StatusAccepted)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Reset password by token sent with Forgot
+func Reset(cfg *config.Config, store Store) http.HandlerFunc {
+	// Reset password by token
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Token    string `json:"token" valid:"required"`
+			Password string `json:"password" valid:"required"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		u, err := store.GetUserByResetToken(ctx, HashToken(rd.Token))
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "invalid or expired token", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		passhash, err := HashPassword(cfg, u.Email, rd.Password)
+		if IsWeakPassword(err) {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			l.Error(err.Error())
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		err = store.ResetUserPassword(ctx, HashToken(rd.Token), passhash)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "invalid or expired token", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		AuditAs(ctx, store, r, u.ID, "password.reset", u.ID.Hex(), nil, nil)
+		l.Info("password reset")
+		http.Error(w, "password reset", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,191 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/mail"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type mailerMock struct {
+	sent []*mail.Message
+}
+
+func (mm *mailerMock) Send(_ context.Context, m *mail.Message) error {
+	mm.sent = append(mm.sent, m)
+
+	return nil
+}
+
+func TestForgot(t *testing.T) {
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockStore
+		body  string
+		code  int
+		sent  int
+	}{
+		{
+			name: "Bad email",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			body: `{"email":"test"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Unknown email",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "test@email.com").
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			body: `{"email":"test@email.com"}`,
+			code: http.StatusAccepted,
+		},
+		{
+			name: "Token sent",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com"}
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "test@email.com").
+					Return(u, nil)
+				store.EXPECT().
+					SetUserResetToken(gomock.Any(), u.ID, gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			body: `{"email":"test@email.com"}`,
+			code: http.StatusAccepted,
+			sent: 1,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			mailer := &mailerMock{}
+
+			handler := auth.Forgot(cfg, store, mailer)
+			req := httptest.NewRequest("POST", "/forgot", strings.NewReader(c.body))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			require.Len(t, mailer.sent, c.sent)
+			for _, m := range mailer.sent {
+				assert.Equal(t, "test@email.com", m.To)
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockStore
+		body  string
+		code  int
+	}{
+		{
+			name: "No token",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			body: `{"password":"correct horse"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid token",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByResetToken(gomock.Any(), gomock.Any()).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			body: `{"token":"token","password":"correct horse"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Weak password",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByResetToken(gomock.Any(), gomock.Any()).
+					Return(&model.User{Email: "test@email.com"}, nil)
+
+				return store
+			},
+			body: `{"token":"token","password":"test@email.com"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Password reset",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByResetToken(gomock.Any(), gomock.Any()).
+					Return(&model.User{Email: "test@email.com"}, nil)
+				store.EXPECT().
+					ResetUserPassword(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			body: `{"token":"token","password":"correct horse"}`,
+			code: http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+
+			handler := auth.Reset(cfg, store)
+			req := httptest.NewRequest("POST", "/reset", strings.NewReader(c.body))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
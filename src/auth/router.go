@@ -7,6 +7,7 @@ import (
 
 	"github.com/l10n-center/api/src/config"
 	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/mail"
 	"github.com/l10n-center/api/src/middleware"
 	"github.com/l10n-center/api/src/model"
 	"github.com/l10n-center/api/src/tracing"
@@ -14,27 +15,49 @@ import (
 	"github.com/asaskevich/govalidator"
 	"github.com/pkg/errors"
 	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/mgo.v2/bson"
 )
 
 // Router return auth section router
-func Router(cfg *config.Config, store Store) func(chi.Router) {
+func Router(cfg *config.Config, keys *Keyring, authn Authenticator, store Store, mailer mail.Mailer) func(chi.Router) {
+	sso := NewOIDC(cfg)
 
 	return func(r chi.Router) {
-		r.Use(WithClaims(cfg, false, false))
+		r.Use(WithClaims(cfg, keys, store, false, false))
 		r.Use(middleware.JSONOnly)
 
-		r.Get("/", Root(cfg, store))
-		r.Post("/init", Init(cfg, store))
-		r.Post("/login", Login(cfg, store))
-		// r.Post("/forgot")
-		// r.Post("/reset")
+		r.Get("/", Root(cfg, keys, store))
+		r.Post("/init", Init(cfg, store, mailer))
+		r.Post("/login", Login(cfg, keys, authn, store))
+		r.Post("/forgot", Forgot(cfg, store, mailer))
+		r.Post("/reset", Reset(cfg, store))
+		r.Post("/verify", Verify(cfg, store))
+		r.Post("/verify/resend", ResendVerification(cfg, store, mailer))
+		r.Post("/accept", Accept(cfg, keys, store))
+		r.Post("/2fa", TwoFactor(cfg, keys, store))
+		r.Post("/2fa/enroll", EnrollTOTP(cfg, keys, store))
+		r.Post("/2fa/enable", EnableTOTP(cfg, store))
+		r.Post("/2fa/disable", DisableTOTP(cfg, store))
+		r.Post("/2fa/recovery", RecoveryCodes(cfg, store))
+		r.Post("/refresh", Refresh(cfg, keys, store))
+		r.Post("/logout", Logout(cfg, store))
+		r.Get("/jwks.json", JWKS(cfg, keys))
+		r.Get("/tokens", AccessTokens(cfg, store))
+		r.Post("/tokens", CreateAccessToken(cfg, store))
+		r.Delete("/tokens/:id", DeleteAccessToken(cfg, store))
+		r.Get("/sessions", Sessions(cfg, store))
+		r.Delete("/sessions/:id", DeleteSession(cfg, store))
+		if sso != nil {
+			r.Get("/oidc/start", OIDCStart(cfg, keys, sso))
+			r.Post("/oidc/callback", OIDCCallback(cfg, keys, store, sso))
+		}
 	}
 }
 
 // Root check login availability and update jwt if present valid
-func Root(cfg *config.Config, store Store) http.HandlerFunc {
+func Root(_ *config.Config, keys *Keyring, store Store) http.HandlerFunc {
 	// Check login availability and update jwt if present valid
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -51,10 +74,8 @@ func Root(cfg *config.Config, store Store) http.HandlerFunc {
 			http.Error(w, "users not found", http.StatusNotFound)
 			return
 		}
-		c, ok := ClaimsFromContext(ctx)
+		c, ok := interactiveClaims(w, r)
 		if !ok {
-			l.Debug("no claims found")
-			http.Error(w, "login required", http.StatusUnauthorized)
 			return
 		}
 		u, err := store.GetUserByID(ctx, c.UserID)
@@ -69,7 +90,7 @@ func Root(cfg *config.Config, store Store) http.HandlerFunc {
 			return
 
 		}
-		st, err := CreateToken(ctx, cfg.Secret, u)
+		st, err := createSessionToken(ctx, keys, store, u, c.SessionID)
 		if err != nil {
 			l.Error(err.Error(), errs.ZapStack(err))
 			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
@@ -81,7 +102,7 @@ func Root(cfg *config.Config, store Store) http.HandlerFunc {
 }
 
 // Login with email/password credentials
-func Login(cfg *config.Config, store Store) http.HandlerFunc {
+func Login(cfg *config.Config, keys *Keyring, authn Authenticator, store Store) http.HandlerFunc {
 	// Login with email/password credentials
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -103,35 +124,64 @@ func Login(cfg *config.Config, store Store) http.HandlerFunc {
 			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
 			return
 		}
-		u, err := store.GetUserByEmail(ctx, rd.Email)
-		if errors.Cause(err) == errs.ModelNotFound {
+		lock, err := ReserveAttempt(ctx, cfg, store, rd.Email, r)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if lock > 0 {
+			l.Sugar().Warnf("login of <%s> is locked for %s", rd.Email, lock)
+			AuditAs(ctx, store, r, "", "login.lock", rd.Email, nil, nil)
+			WriteLocked(w, lock)
+			return
+		}
+		u, err := authn.Authenticate(ctx, rd.Email, rd.Password)
+		if errors.Cause(err) == ErrInvalidCredentials {
+			// Reserved attempt is left counted as failure
 			l.Debug(err.Error(), zap.Error(err))
-			http.Error(w, "invalid email or password", http.StatusUnauthorized)
+			AuditAs(ctx, store, r, "", "login.fail", rd.Email, nil, nil)
+			http.Error(w, ErrInvalidCredentials.Error(), http.StatusUnauthorized)
 			return
 		} else if err != nil {
 			l.Error(err.Error(), errs.ZapStack(err))
 			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
 			return
 		}
-		if err = bcrypt.CompareHashAndPassword(u.Passhash, []byte(rd.Password)); err != nil {
-			l.Debug("invalid password", zap.Error(err))
-			http.Error(w, "invalid email or password", http.StatusUnauthorized)
+		if err = ReleaseAttempt(ctx, store, rd.Email, r); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
 			return
 		}
-		st, err := CreateToken(ctx, cfg.Secret, u)
+		res, status, err := login(ctx, cfg, keys, store, r, u)
+		if errors.Cause(err) == ErrEmailNotVerified {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, ErrEmailNotVerified.Error(), http.StatusForbidden)
+			return
+		}
+		if err == nil && status == http.StatusOK {
+			err = resetLogin(ctx, store, rd.Email)
+		}
 		if err != nil {
 			l.Error(err.Error(), errs.ZapStack(err))
 			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
 			return
 		}
+		if status == http.StatusOK {
+			AuditAs(ctx, store, r, u.ID, "login", u.ID.Hex(), nil, nil)
+		}
 		l.Sugar().Infof("user <%s> is logined", rd.Email)
-		http.Error(w, st, http.StatusOK)
+		render.Status(r, status)
+		render.JSON(w, r, res)
 	}
 	return http.HandlerFunc(fn)
 }
 
 // Init create admin user
-func Init(_ *config.Config, store Store) http.HandlerFunc {
+//
+// Email of admin is verified by sent token like of any other user,
+// config.ExemptUnverifiedAdmins let admin login before it
+func Init(cfg *config.Config, store Store, mailer mail.Mailer) http.HandlerFunc {
 	// Create admin user
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -154,8 +204,12 @@ func Init(_ *config.Config, store Store) http.HandlerFunc {
 			return
 		}
 
-		passhash, err := bcrypt.GenerateFromPassword([]byte(rd.Password), bcrypt.DefaultCost)
-		if err != nil {
+		passhash, err := HashPassword(cfg, rd.Email, rd.Password)
+		if IsWeakPassword(err) {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
 			l.Error(err.Error())
 			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
 			return
@@ -173,21 +227,35 @@ func Init(_ *config.Config, store Store) http.HandlerFunc {
 			return
 		}
 
-		err = store.CreateUser(ctx, &model.User{
+		u := &model.User{
+			ID:         bson.NewObjectId(),
 			Email:      rd.Email,
 			Passhash:   passhash,
 			IsAdmin:    true,
 			Permission: model.CanEverything,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
-		})
-		if err != nil {
+		}
+		if err = store.CreateUser(ctx, u); err != nil {
 			l.Error(err.Error(), errs.ZapStack(err))
 			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
 			return
 		}
+		AuditAs(ctx, store, r, u.ID, "admin.init", u.ID.Hex(), nil, u)
+		if err = SendVerification(ctx, store, mailer, u, u.Email); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+		}
 		l.Sugar().Infof("admin created with email <%s>", rd.Email)
 		http.Error(w, "admin created", http.StatusCreated)
 	}
 	return http.HandlerFunc(fn)
 }
+
+// JWKS publish public keys to verify tokens
+func JWKS(_ *config.Config, keys *Keyring) http.HandlerFunc {
+	// Publish public keys to verify tokens
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, keys.JWKS())
+	}
+	return http.HandlerFunc(fn)
+}
@@ -146,12 +146,18 @@ func TestRoot(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
 
-			handler := auth.Root(cfg, store)
+			handler := auth.Root(cfg, keys, store)
 			req := httptest.NewRequest("GET", "/", nil)
 			res := httptest.NewRecorder()
 
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/pkg/errors"
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// maxUserAgentLength limit stored user agent of session
+const maxUserAgentLength = 256
+
+// startSession record new session of user on client of request
+// and issue first tokens of it
+func startSession(ctx context.Context, keys *Keyring, store Store, r *http.Request, u *model.User) (*Tokens, error) {
+	ua := r.UserAgent()
+	if len(ua) > maxUserAgentLength {
+		ua = ua[:maxUserAgentLength]
+	}
+	now := time.Now()
+	ss := &model.Session{
+		ID:         bson.NewObjectId(),
+		UserID:     u.ID,
+		UserAgent:  ua,
+		IP:         clientIP(r),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(RefreshTokenTTL),
+	}
+	if err := store.CreateSession(ctx, ss); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return IssueTokens(ctx, keys, store, u, ss.ID)
+}
+
+// Sessions list sessions of current user
+func Sessions(_ *config.Config, store Store) http.HandlerFunc {
+	// List sessions of current user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		c, ok := interactiveClaims(w, r)
+		if !ok {
+			return
+		}
+		sl, err := store.GetSessionsByUser(ctx, c.UserID)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		for _, ss := range sl {
+			ss.Current = ss.ID == c.SessionID
+		}
+		render.JSON(w, r, sl)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// DeleteSession revoke session of current user with all it's tokens
+func DeleteSession(_ *config.Config, store Store) http.HandlerFunc {
+	// Revoke session of current user with all it's tokens
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		c, ok := interactiveClaims(w, r)
+		if !ok {
+			return
+		}
+		id := chi.URLParam(r, "id")
+		if !bson.IsObjectIdHex(id) {
+			l.Debug("bad session id")
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		err := store.DeleteSession(ctx, c.UserID, bson.ObjectIdHex(id))
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		Audit(ctx, store, r, "session.revoke", id, nil, nil)
+		l.Sugar().Infof("session <%s> revoked by <%s>", id, c.Email)
+		http.Error(w, "session revoked", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,233 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pressly/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestWithClaimsSession(t *testing.T) {
+	u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com", TokenVersion: 1}
+	sid := bson.NewObjectId()
+
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockClaimsStore
+		code  int
+	}{
+		{
+			name: "Revoked session",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					GetSessionByID(gomock.Any(), sid).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name: "Session of other user",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					GetSessionByID(gomock.Any(), sid).
+					Return(&model.Session{ID: sid, UserID: bson.NewObjectId(), LastSeenAt: time.Now()}, nil)
+
+				return store
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name: "Recently seen",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					GetSessionByID(gomock.Any(), sid).
+					Return(&model.Session{ID: sid, UserID: u.ID, LastSeenAt: time.Now()}, nil)
+
+				return store
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "Touched",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					GetSessionByID(gomock.Any(), sid).
+					Return(&model.Session{ID: sid, UserID: u.ID, LastSeenAt: time.Now().Add(-time.Hour)}, nil)
+				store.EXPECT().
+					TouchSession(gomock.Any(), sid, gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			code: http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+	claims := &auth.Claims{UserID: u.ID, Email: u.Email, Version: u.TokenVersion, SessionID: sid}
+	claims.Id = bson.NewObjectId().Hex()
+	claims.ExpiresAt = time.Now().Add(auth.AccessTokenTTL).Unix()
+	st, err := keys.Sign(claims)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				IsTokenRevoked(gomock.Any(), gomock.Any()).
+				Return(false, nil)
+			store.EXPECT().
+				GetUserByID(gomock.Any(), u.ID).
+				Return(u, nil)
+
+			handler := auth.WithClaims(cfg, keys, store, true, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c, ok := auth.ClaimsFromContext(r.Context())
+				require.True(t, ok)
+				assert.Equal(t, sid, c.SessionID)
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req = req.WithContext(opentracing.ContextWithSpan(req.Context(), opentracing.NoopTracer{}.StartSpan("test")))
+			req.Header.Set("Authorization", "Bearer "+st)
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
+
+func TestSessions(t *testing.T) {
+	c := &auth.Claims{UserID: bson.NewObjectId(), SessionID: bson.NewObjectId()}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	store := NewMockStore(mockCtrl)
+	store.EXPECT().
+		GetSessionsByUser(gomock.Any(), c.UserID).
+		Return([]*model.Session{
+			{ID: c.SessionID, UserID: c.UserID},
+			{ID: bson.NewObjectId(), UserID: c.UserID},
+		}, nil)
+
+	handler := auth.Sessions(config.Default(), store)
+	req := httptest.NewRequest("GET", "/sessions", nil)
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), c))
+	res := httptest.NewRecorder()
+
+	handler(res, req)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.Equal(t, 1, strings.Count(res.Body.String(), `"current":true`))
+}
+
+func TestDeleteSession(t *testing.T) {
+	c := &auth.Claims{UserID: bson.NewObjectId(), Email: "test@email.com"}
+	id := bson.NewObjectId()
+
+	cases := []struct {
+		name   string
+		store  func(*gomock.Controller) *MockStore
+		claims *auth.Claims
+		id     string
+		code   int
+	}{
+		{
+			name: "By access token",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			claims: &auth.Claims{UserID: c.UserID, TokenID: bson.NewObjectId()},
+			id:     id.Hex(),
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Bad id",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			claims: c,
+			id:     "bad",
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Not found",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					DeleteSession(gomock.Any(), c.UserID, id).
+					Return(errs.ModelNotFound)
+
+				return store
+			},
+			claims: c,
+			id:     id.Hex(),
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Revoked",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					DeleteSession(gomock.Any(), c.UserID, id).
+					Return(nil)
+
+				return store
+			},
+			claims: c,
+			id:     id.Hex(),
+			code:   http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+
+			r := chi.NewRouter()
+			r.Delete("/sessions/:id", auth.DeleteSession(cfg, store))
+			req := httptest.NewRequest("DELETE", "/sessions/"+c.id, nil)
+			req = req.WithContext(auth.ContextWithClaims(context.Background(), c.claims))
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"time"
 
 	"github.com/l10n-center/api/src/model"
 
@@ -10,10 +11,76 @@ import (
 
 //go:generate mockgen -source=store.go -destination=store_mock_test.go -package=auth_test
 
+// ClaimsStore is a interface of store required to check claims in WithClaims
+type ClaimsStore interface {
+	GetUserByID(context.Context, bson.ObjectId) (*model.User, error)
+	IsTokenRevoked(context.Context, string) (bool, error)
+	GetAccessTokenByHash(context.Context, []byte) (*model.AccessToken, error)
+	TouchAccessToken(context.Context, bson.ObjectId, time.Time) error
+	GetRolesByNames(context.Context, []string) ([]*model.Role, error)
+	GetGroupsByIDs(context.Context, []bson.ObjectId) ([]*model.Group, error)
+	GetSessionByID(context.Context, bson.ObjectId) (*model.Session, error)
+	GetServiceAccountByID(context.Context, bson.ObjectId) (*model.ServiceAccount, error)
+	TouchSession(context.Context, bson.ObjectId, time.Time, time.Time) error
+}
+
+// AuditStore is a interface of store required to record audit entries
+type AuditStore interface {
+	CreateAuditEntry(context.Context, *model.AuditEntry) error
+}
+
+// VerifyStore is a interface of store required to send email verification
+type VerifyStore interface {
+	SetUserEmailChange(context.Context, bson.ObjectId, string, []byte, time.Time) error
+}
+
+// ThrottleStore is a interface of store required to throttle login attempts
+type ThrottleStore interface {
+	GetLoginThrottles(context.Context, []string) ([]*model.LoginThrottle, error)
+	FailLogin(context.Context, string, time.Time, time.Time) (*model.LoginThrottle, error)
+	ReleaseLogin(context.Context, string) error
+	DeleteLoginThrottles(context.Context, []string) error
+}
+
+// SessionStore is a interface of store required to issue tokens of session
+type SessionStore interface {
+	ClaimsStore
+	CreateRefreshToken(context.Context, *model.RefreshToken) error
+}
+
 // Store is a interface of store required in package auth
 type Store interface {
+	ClaimsStore
+	AuditStore
+	VerifyStore
+	ThrottleStore
 	GetUserCount(context.Context) (int, error)
-	GetUserByID(context.Context, bson.ObjectId) (*model.User, error)
 	GetUserByEmail(context.Context, string) (*model.User, error)
+	HasDeletedUser(context.Context, string) (bool, error)
 	CreateUser(context.Context, *model.User) error
+	UpdateUser(context.Context, bson.ObjectId, *model.UserChange) error
+	SetUserResetToken(context.Context, bson.ObjectId, []byte, time.Time) error
+	ResetUserPassword(context.Context, []byte, []byte) error
+	GetUserByResetToken(context.Context, []byte) (*model.User, error)
+	UpdateUserPasshash(context.Context, bson.ObjectId, []byte) error
+	GetUserByEmailToken(context.Context, []byte) (*model.User, error)
+	ConfirmUserEmail(context.Context, bson.ObjectId, []byte) error
+	AcceptUserInvite(context.Context, bson.ObjectId, string, []byte) error
+	SetUserTOTPSecret(context.Context, bson.ObjectId, string) error
+	EnableUserTOTP(context.Context, bson.ObjectId, int64, [][]byte) error
+	DisableUserTOTP(context.Context, bson.ObjectId) error
+	UseUserTOTPStep(context.Context, bson.ObjectId, int64) error
+	UseUserRecoveryCode(context.Context, bson.ObjectId, []byte) error
+	SetUserRecoveryCodes(context.Context, bson.ObjectId, [][]byte) error
+	CreateRefreshToken(context.Context, *model.RefreshToken) error
+	GetRefreshTokenByHash(context.Context, []byte) (*model.RefreshToken, error)
+	UseRefreshToken(context.Context, bson.ObjectId) error
+	DeleteRefreshTokenFamily(context.Context, bson.ObjectId) error
+	CreateSession(context.Context, *model.Session) error
+	GetSessionsByUser(context.Context, bson.ObjectId) ([]*model.Session, error)
+	DeleteSession(context.Context, bson.ObjectId, bson.ObjectId) error
+	RevokeToken(context.Context, string, time.Time) error
+	CreateAccessToken(context.Context, *model.AccessToken) error
+	GetAccessTokensByUser(context.Context, bson.ObjectId) ([]*model.AccessToken, error)
+	DeleteAccessToken(context.Context, bson.ObjectId, bson.ObjectId) error
 }
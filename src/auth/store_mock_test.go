@@ -8,8 +8,380 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/l10n-center/api/src/model"
 	bson "gopkg.in/mgo.v2/bson"
+	time "time"
 )
 
+// Mock of ClaimsStore interface
+type MockClaimsStore struct {
+	ctrl     *gomock.Controller
+	recorder *_MockClaimsStoreRecorder
+}
+
+// Recorder for MockClaimsStore (not exported)
+type _MockClaimsStoreRecorder struct {
+	mock *MockClaimsStore
+}
+
+func NewMockClaimsStore(ctrl *gomock.Controller) *MockClaimsStore {
+	mock := &MockClaimsStore{ctrl: ctrl}
+	mock.recorder = &_MockClaimsStoreRecorder{mock}
+	return mock
+}
+
+func (_m *MockClaimsStore) EXPECT() *_MockClaimsStoreRecorder {
+	return _m.recorder
+}
+
+func (_m *MockClaimsStore) GetUserByID(_param0 context.Context, _param1 bson.ObjectId) (*model.User, error) {
+	ret := _m.ctrl.Call(_m, "GetUserByID", _param0, _param1)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClaimsStoreRecorder) GetUserByID(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetUserByID", arg0, arg1)
+}
+
+func (_m *MockClaimsStore) IsTokenRevoked(_param0 context.Context, _param1 string) (bool, error) {
+	ret := _m.ctrl.Call(_m, "IsTokenRevoked", _param0, _param1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClaimsStoreRecorder) IsTokenRevoked(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "IsTokenRevoked", arg0, arg1)
+}
+
+func (_m *MockClaimsStore) GetAccessTokenByHash(_param0 context.Context, _param1 []byte) (*model.AccessToken, error) {
+	ret := _m.ctrl.Call(_m, "GetAccessTokenByHash", _param0, _param1)
+	ret0, _ := ret[0].(*model.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClaimsStoreRecorder) GetAccessTokenByHash(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetAccessTokenByHash", arg0, arg1)
+}
+
+func (_m *MockClaimsStore) TouchAccessToken(_param0 context.Context, _param1 bson.ObjectId, _param2 time.Time) error {
+	ret := _m.ctrl.Call(_m, "TouchAccessToken", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockClaimsStoreRecorder) TouchAccessToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "TouchAccessToken", arg0, arg1, arg2)
+}
+
+func (_m *MockClaimsStore) GetRolesByNames(_param0 context.Context, _param1 []string) ([]*model.Role, error) {
+	ret := _m.ctrl.Call(_m, "GetRolesByNames", _param0, _param1)
+	ret0, _ := ret[0].([]*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClaimsStoreRecorder) GetRolesByNames(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetRolesByNames", arg0, arg1)
+}
+
+func (_m *MockClaimsStore) GetGroupsByIDs(_param0 context.Context, _param1 []bson.ObjectId) ([]*model.Group, error) {
+	ret := _m.ctrl.Call(_m, "GetGroupsByIDs", _param0, _param1)
+	ret0, _ := ret[0].([]*model.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClaimsStoreRecorder) GetGroupsByIDs(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetGroupsByIDs", arg0, arg1)
+}
+
+func (_m *MockClaimsStore) GetSessionByID(_param0 context.Context, _param1 bson.ObjectId) (*model.Session, error) {
+	ret := _m.ctrl.Call(_m, "GetSessionByID", _param0, _param1)
+	ret0, _ := ret[0].(*model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClaimsStoreRecorder) GetSessionByID(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetSessionByID", arg0, arg1)
+}
+
+func (_m *MockClaimsStore) GetServiceAccountByID(_param0 context.Context, _param1 bson.ObjectId) (*model.ServiceAccount, error) {
+	ret := _m.ctrl.Call(_m, "GetServiceAccountByID", _param0, _param1)
+	ret0, _ := ret[0].(*model.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClaimsStoreRecorder) GetServiceAccountByID(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetServiceAccountByID", arg0, arg1)
+}
+
+func (_m *MockClaimsStore) TouchSession(_param0 context.Context, _param1 bson.ObjectId, _param2 time.Time, _param3 time.Time) error {
+	ret := _m.ctrl.Call(_m, "TouchSession", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockClaimsStoreRecorder) TouchSession(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "TouchSession", arg0, arg1, arg2, arg3)
+}
+
+// Mock of AuditStore interface
+type MockAuditStore struct {
+	ctrl     *gomock.Controller
+	recorder *_MockAuditStoreRecorder
+}
+
+// Recorder for MockAuditStore (not exported)
+type _MockAuditStoreRecorder struct {
+	mock *MockAuditStore
+}
+
+func NewMockAuditStore(ctrl *gomock.Controller) *MockAuditStore {
+	mock := &MockAuditStore{ctrl: ctrl}
+	mock.recorder = &_MockAuditStoreRecorder{mock}
+	return mock
+}
+
+func (_m *MockAuditStore) EXPECT() *_MockAuditStoreRecorder {
+	return _m.recorder
+}
+
+func (_m *MockAuditStore) CreateAuditEntry(_param0 context.Context, _param1 *model.AuditEntry) error {
+	ret := _m.ctrl.Call(_m, "CreateAuditEntry", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockAuditStoreRecorder) CreateAuditEntry(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateAuditEntry", arg0, arg1)
+}
+
+// Mock of VerifyStore interface
+type MockVerifyStore struct {
+	ctrl     *gomock.Controller
+	recorder *_MockVerifyStoreRecorder
+}
+
+// Recorder for MockVerifyStore (not exported)
+type _MockVerifyStoreRecorder struct {
+	mock *MockVerifyStore
+}
+
+func NewMockVerifyStore(ctrl *gomock.Controller) *MockVerifyStore {
+	mock := &MockVerifyStore{ctrl: ctrl}
+	mock.recorder = &_MockVerifyStoreRecorder{mock}
+	return mock
+}
+
+func (_m *MockVerifyStore) EXPECT() *_MockVerifyStoreRecorder {
+	return _m.recorder
+}
+
+func (_m *MockVerifyStore) SetUserEmailChange(_param0 context.Context, _param1 bson.ObjectId, _param2 string, _param3 []byte, _param4 time.Time) error {
+	ret := _m.ctrl.Call(_m, "SetUserEmailChange", _param0, _param1, _param2, _param3, _param4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockVerifyStoreRecorder) SetUserEmailChange(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetUserEmailChange", arg0, arg1, arg2, arg3, arg4)
+}
+
+// Mock of ThrottleStore interface
+type MockThrottleStore struct {
+	ctrl     *gomock.Controller
+	recorder *_MockThrottleStoreRecorder
+}
+
+// Recorder for MockThrottleStore (not exported)
+type _MockThrottleStoreRecorder struct {
+	mock *MockThrottleStore
+}
+
+func NewMockThrottleStore(ctrl *gomock.Controller) *MockThrottleStore {
+	mock := &MockThrottleStore{ctrl: ctrl}
+	mock.recorder = &_MockThrottleStoreRecorder{mock}
+	return mock
+}
+
+func (_m *MockThrottleStore) EXPECT() *_MockThrottleStoreRecorder {
+	return _m.recorder
+}
+
+func (_m *MockThrottleStore) GetLoginThrottles(_param0 context.Context, _param1 []string) ([]*model.LoginThrottle, error) {
+	ret := _m.ctrl.Call(_m, "GetLoginThrottles", _param0, _param1)
+	ret0, _ := ret[0].([]*model.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockThrottleStoreRecorder) GetLoginThrottles(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetLoginThrottles", arg0, arg1)
+}
+
+func (_m *MockThrottleStore) FailLogin(_param0 context.Context, _param1 string, _param2 time.Time, _param3 time.Time) (*model.LoginThrottle, error) {
+	ret := _m.ctrl.Call(_m, "FailLogin", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(*model.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockThrottleStoreRecorder) FailLogin(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "FailLogin", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockThrottleStore) ReleaseLogin(_param0 context.Context, _param1 string) error {
+	ret := _m.ctrl.Call(_m, "ReleaseLogin", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockThrottleStoreRecorder) ReleaseLogin(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReleaseLogin", arg0, arg1)
+}
+
+func (_m *MockThrottleStore) DeleteLoginThrottles(_param0 context.Context, _param1 []string) error {
+	ret := _m.ctrl.Call(_m, "DeleteLoginThrottles", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockThrottleStoreRecorder) DeleteLoginThrottles(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteLoginThrottles", arg0, arg1)
+}
+
+// Mock of SessionStore interface
+type MockSessionStore struct {
+	ctrl     *gomock.Controller
+	recorder *_MockSessionStoreRecorder
+}
+
+// Recorder for MockSessionStore (not exported)
+type _MockSessionStoreRecorder struct {
+	mock *MockSessionStore
+}
+
+func NewMockSessionStore(ctrl *gomock.Controller) *MockSessionStore {
+	mock := &MockSessionStore{ctrl: ctrl}
+	mock.recorder = &_MockSessionStoreRecorder{mock}
+	return mock
+}
+
+func (_m *MockSessionStore) EXPECT() *_MockSessionStoreRecorder {
+	return _m.recorder
+}
+
+func (_m *MockSessionStore) GetUserByID(_param0 context.Context, _param1 bson.ObjectId) (*model.User, error) {
+	ret := _m.ctrl.Call(_m, "GetUserByID", _param0, _param1)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockSessionStoreRecorder) GetUserByID(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetUserByID", arg0, arg1)
+}
+
+func (_m *MockSessionStore) IsTokenRevoked(_param0 context.Context, _param1 string) (bool, error) {
+	ret := _m.ctrl.Call(_m, "IsTokenRevoked", _param0, _param1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockSessionStoreRecorder) IsTokenRevoked(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "IsTokenRevoked", arg0, arg1)
+}
+
+func (_m *MockSessionStore) GetAccessTokenByHash(_param0 context.Context, _param1 []byte) (*model.AccessToken, error) {
+	ret := _m.ctrl.Call(_m, "GetAccessTokenByHash", _param0, _param1)
+	ret0, _ := ret[0].(*model.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockSessionStoreRecorder) GetAccessTokenByHash(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetAccessTokenByHash", arg0, arg1)
+}
+
+func (_m *MockSessionStore) TouchAccessToken(_param0 context.Context, _param1 bson.ObjectId, _param2 time.Time) error {
+	ret := _m.ctrl.Call(_m, "TouchAccessToken", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockSessionStoreRecorder) TouchAccessToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "TouchAccessToken", arg0, arg1, arg2)
+}
+
+func (_m *MockSessionStore) GetRolesByNames(_param0 context.Context, _param1 []string) ([]*model.Role, error) {
+	ret := _m.ctrl.Call(_m, "GetRolesByNames", _param0, _param1)
+	ret0, _ := ret[0].([]*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockSessionStoreRecorder) GetRolesByNames(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetRolesByNames", arg0, arg1)
+}
+
+func (_m *MockSessionStore) GetGroupsByIDs(_param0 context.Context, _param1 []bson.ObjectId) ([]*model.Group, error) {
+	ret := _m.ctrl.Call(_m, "GetGroupsByIDs", _param0, _param1)
+	ret0, _ := ret[0].([]*model.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockSessionStoreRecorder) GetGroupsByIDs(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetGroupsByIDs", arg0, arg1)
+}
+
+func (_m *MockSessionStore) GetSessionByID(_param0 context.Context, _param1 bson.ObjectId) (*model.Session, error) {
+	ret := _m.ctrl.Call(_m, "GetSessionByID", _param0, _param1)
+	ret0, _ := ret[0].(*model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockSessionStoreRecorder) GetSessionByID(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetSessionByID", arg0, arg1)
+}
+
+func (_m *MockSessionStore) GetServiceAccountByID(_param0 context.Context, _param1 bson.ObjectId) (*model.ServiceAccount, error) {
+	ret := _m.ctrl.Call(_m, "GetServiceAccountByID", _param0, _param1)
+	ret0, _ := ret[0].(*model.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockSessionStoreRecorder) GetServiceAccountByID(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetServiceAccountByID", arg0, arg1)
+}
+
+func (_m *MockSessionStore) TouchSession(_param0 context.Context, _param1 bson.ObjectId, _param2 time.Time, _param3 time.Time) error {
+	ret := _m.ctrl.Call(_m, "TouchSession", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockSessionStoreRecorder) TouchSession(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "TouchSession", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockSessionStore) CreateRefreshToken(_param0 context.Context, _param1 *model.RefreshToken) error {
+	ret := _m.ctrl.Call(_m, "CreateRefreshToken", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockSessionStoreRecorder) CreateRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateRefreshToken", arg0, arg1)
+}
+
 // Mock of Store interface
 type MockStore struct {
 	ctrl     *gomock.Controller
@@ -31,6 +403,165 @@ func (_m *MockStore) EXPECT() *_MockStoreRecorder {
 	return _m.recorder
 }
 
+func (_m *MockStore) GetUserByID(_param0 context.Context, _param1 bson.ObjectId) (*model.User, error) {
+	ret := _m.ctrl.Call(_m, "GetUserByID", _param0, _param1)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetUserByID(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetUserByID", arg0, arg1)
+}
+
+func (_m *MockStore) IsTokenRevoked(_param0 context.Context, _param1 string) (bool, error) {
+	ret := _m.ctrl.Call(_m, "IsTokenRevoked", _param0, _param1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) IsTokenRevoked(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "IsTokenRevoked", arg0, arg1)
+}
+
+func (_m *MockStore) GetAccessTokenByHash(_param0 context.Context, _param1 []byte) (*model.AccessToken, error) {
+	ret := _m.ctrl.Call(_m, "GetAccessTokenByHash", _param0, _param1)
+	ret0, _ := ret[0].(*model.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetAccessTokenByHash(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetAccessTokenByHash", arg0, arg1)
+}
+
+func (_m *MockStore) TouchAccessToken(_param0 context.Context, _param1 bson.ObjectId, _param2 time.Time) error {
+	ret := _m.ctrl.Call(_m, "TouchAccessToken", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) TouchAccessToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "TouchAccessToken", arg0, arg1, arg2)
+}
+
+func (_m *MockStore) GetRolesByNames(_param0 context.Context, _param1 []string) ([]*model.Role, error) {
+	ret := _m.ctrl.Call(_m, "GetRolesByNames", _param0, _param1)
+	ret0, _ := ret[0].([]*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetRolesByNames(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetRolesByNames", arg0, arg1)
+}
+
+func (_m *MockStore) GetGroupsByIDs(_param0 context.Context, _param1 []bson.ObjectId) ([]*model.Group, error) {
+	ret := _m.ctrl.Call(_m, "GetGroupsByIDs", _param0, _param1)
+	ret0, _ := ret[0].([]*model.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetGroupsByIDs(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetGroupsByIDs", arg0, arg1)
+}
+
+func (_m *MockStore) GetSessionByID(_param0 context.Context, _param1 bson.ObjectId) (*model.Session, error) {
+	ret := _m.ctrl.Call(_m, "GetSessionByID", _param0, _param1)
+	ret0, _ := ret[0].(*model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetSessionByID(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetSessionByID", arg0, arg1)
+}
+
+func (_m *MockStore) GetServiceAccountByID(_param0 context.Context, _param1 bson.ObjectId) (*model.ServiceAccount, error) {
+	ret := _m.ctrl.Call(_m, "GetServiceAccountByID", _param0, _param1)
+	ret0, _ := ret[0].(*model.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetServiceAccountByID(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetServiceAccountByID", arg0, arg1)
+}
+
+func (_m *MockStore) TouchSession(_param0 context.Context, _param1 bson.ObjectId, _param2 time.Time, _param3 time.Time) error {
+	ret := _m.ctrl.Call(_m, "TouchSession", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) TouchSession(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "TouchSession", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockStore) CreateAuditEntry(_param0 context.Context, _param1 *model.AuditEntry) error {
+	ret := _m.ctrl.Call(_m, "CreateAuditEntry", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) CreateAuditEntry(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateAuditEntry", arg0, arg1)
+}
+
+func (_m *MockStore) SetUserEmailChange(_param0 context.Context, _param1 bson.ObjectId, _param2 string, _param3 []byte, _param4 time.Time) error {
+	ret := _m.ctrl.Call(_m, "SetUserEmailChange", _param0, _param1, _param2, _param3, _param4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) SetUserEmailChange(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetUserEmailChange", arg0, arg1, arg2, arg3, arg4)
+}
+
+func (_m *MockStore) GetLoginThrottles(_param0 context.Context, _param1 []string) ([]*model.LoginThrottle, error) {
+	ret := _m.ctrl.Call(_m, "GetLoginThrottles", _param0, _param1)
+	ret0, _ := ret[0].([]*model.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetLoginThrottles(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetLoginThrottles", arg0, arg1)
+}
+
+func (_m *MockStore) FailLogin(_param0 context.Context, _param1 string, _param2 time.Time, _param3 time.Time) (*model.LoginThrottle, error) {
+	ret := _m.ctrl.Call(_m, "FailLogin", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(*model.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) FailLogin(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "FailLogin", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockStore) ReleaseLogin(_param0 context.Context, _param1 string) error {
+	ret := _m.ctrl.Call(_m, "ReleaseLogin", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) ReleaseLogin(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReleaseLogin", arg0, arg1)
+}
+
+func (_m *MockStore) DeleteLoginThrottles(_param0 context.Context, _param1 []string) error {
+	ret := _m.ctrl.Call(_m, "DeleteLoginThrottles", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) DeleteLoginThrottles(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteLoginThrottles", arg0, arg1)
+}
+
 func (_m *MockStore) GetUserCount(_param0 context.Context) (int, error) {
 	ret := _m.ctrl.Call(_m, "GetUserCount", _param0)
 	ret0, _ := ret[0].(int)
@@ -42,26 +573,26 @@ func (_mr *_MockStoreRecorder) GetUserCount(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetUserCount", arg0)
 }
 
-func (_m *MockStore) GetUserByID(_param0 context.Context, _param1 bson.ObjectId) (*model.User, error) {
-	ret := _m.ctrl.Call(_m, "GetUserByID", _param0, _param1)
+func (_m *MockStore) GetUserByEmail(_param0 context.Context, _param1 string) (*model.User, error) {
+	ret := _m.ctrl.Call(_m, "GetUserByEmail", _param0, _param1)
 	ret0, _ := ret[0].(*model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (_mr *_MockStoreRecorder) GetUserByID(arg0, arg1 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetUserByID", arg0, arg1)
+func (_mr *_MockStoreRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetUserByEmail", arg0, arg1)
 }
 
-func (_m *MockStore) GetUserByEmail(_param0 context.Context, _param1 string) (*model.User, error) {
-	ret := _m.ctrl.Call(_m, "GetUserByEmail", _param0, _param1)
-	ret0, _ := ret[0].(*model.User)
+func (_m *MockStore) HasDeletedUser(_param0 context.Context, _param1 string) (bool, error) {
+	ret := _m.ctrl.Call(_m, "HasDeletedUser", _param0, _param1)
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (_mr *_MockStoreRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetUserByEmail", arg0, arg1)
+func (_mr *_MockStoreRecorder) HasDeletedUser(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "HasDeletedUser", arg0, arg1)
 }
 
 func (_m *MockStore) CreateUser(_param0 context.Context, _param1 *model.User) error {
@@ -73,3 +604,258 @@ func (_m *MockStore) CreateUser(_param0 context.Context, _param1 *model.User) er
 func (_mr *_MockStoreRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateUser", arg0, arg1)
 }
+
+func (_m *MockStore) UpdateUser(_param0 context.Context, _param1 bson.ObjectId, _param2 *model.UserChange) error {
+	ret := _m.ctrl.Call(_m, "UpdateUser", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) UpdateUser(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UpdateUser", arg0, arg1, arg2)
+}
+
+func (_m *MockStore) SetUserResetToken(_param0 context.Context, _param1 bson.ObjectId, _param2 []byte, _param3 time.Time) error {
+	ret := _m.ctrl.Call(_m, "SetUserResetToken", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) SetUserResetToken(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetUserResetToken", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockStore) ResetUserPassword(_param0 context.Context, _param1 []byte, _param2 []byte) error {
+	ret := _m.ctrl.Call(_m, "ResetUserPassword", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) ResetUserPassword(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ResetUserPassword", arg0, arg1, arg2)
+}
+
+func (_m *MockStore) GetUserByResetToken(_param0 context.Context, _param1 []byte) (*model.User, error) {
+	ret := _m.ctrl.Call(_m, "GetUserByResetToken", _param0, _param1)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetUserByResetToken(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetUserByResetToken", arg0, arg1)
+}
+
+func (_m *MockStore) UpdateUserPasshash(_param0 context.Context, _param1 bson.ObjectId, _param2 []byte) error {
+	ret := _m.ctrl.Call(_m, "UpdateUserPasshash", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) UpdateUserPasshash(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UpdateUserPasshash", arg0, arg1, arg2)
+}
+
+func (_m *MockStore) GetUserByEmailToken(_param0 context.Context, _param1 []byte) (*model.User, error) {
+	ret := _m.ctrl.Call(_m, "GetUserByEmailToken", _param0, _param1)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetUserByEmailToken(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetUserByEmailToken", arg0, arg1)
+}
+
+func (_m *MockStore) ConfirmUserEmail(_param0 context.Context, _param1 bson.ObjectId, _param2 []byte) error {
+	ret := _m.ctrl.Call(_m, "ConfirmUserEmail", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) ConfirmUserEmail(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ConfirmUserEmail", arg0, arg1, arg2)
+}
+
+func (_m *MockStore) AcceptUserInvite(_param0 context.Context, _param1 bson.ObjectId, _param2 string, _param3 []byte) error {
+	ret := _m.ctrl.Call(_m, "AcceptUserInvite", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) AcceptUserInvite(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "AcceptUserInvite", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockStore) SetUserTOTPSecret(_param0 context.Context, _param1 bson.ObjectId, _param2 string) error {
+	ret := _m.ctrl.Call(_m, "SetUserTOTPSecret", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) SetUserTOTPSecret(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetUserTOTPSecret", arg0, arg1, arg2)
+}
+
+func (_m *MockStore) EnableUserTOTP(_param0 context.Context, _param1 bson.ObjectId, _param2 int64, _param3 [][]byte) error {
+	ret := _m.ctrl.Call(_m, "EnableUserTOTP", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) EnableUserTOTP(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "EnableUserTOTP", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockStore) DisableUserTOTP(_param0 context.Context, _param1 bson.ObjectId) error {
+	ret := _m.ctrl.Call(_m, "DisableUserTOTP", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) DisableUserTOTP(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DisableUserTOTP", arg0, arg1)
+}
+
+func (_m *MockStore) UseUserTOTPStep(_param0 context.Context, _param1 bson.ObjectId, _param2 int64) error {
+	ret := _m.ctrl.Call(_m, "UseUserTOTPStep", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) UseUserTOTPStep(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UseUserTOTPStep", arg0, arg1, arg2)
+}
+
+func (_m *MockStore) UseUserRecoveryCode(_param0 context.Context, _param1 bson.ObjectId, _param2 []byte) error {
+	ret := _m.ctrl.Call(_m, "UseUserRecoveryCode", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) UseUserRecoveryCode(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UseUserRecoveryCode", arg0, arg1, arg2)
+}
+
+func (_m *MockStore) SetUserRecoveryCodes(_param0 context.Context, _param1 bson.ObjectId, _param2 [][]byte) error {
+	ret := _m.ctrl.Call(_m, "SetUserRecoveryCodes", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) SetUserRecoveryCodes(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetUserRecoveryCodes", arg0, arg1, arg2)
+}
+
+func (_m *MockStore) CreateRefreshToken(_param0 context.Context, _param1 *model.RefreshToken) error {
+	ret := _m.ctrl.Call(_m, "CreateRefreshToken", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) CreateRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateRefreshToken", arg0, arg1)
+}
+
+func (_m *MockStore) GetRefreshTokenByHash(_param0 context.Context, _param1 []byte) (*model.RefreshToken, error) {
+	ret := _m.ctrl.Call(_m, "GetRefreshTokenByHash", _param0, _param1)
+	ret0, _ := ret[0].(*model.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetRefreshTokenByHash(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetRefreshTokenByHash", arg0, arg1)
+}
+
+func (_m *MockStore) UseRefreshToken(_param0 context.Context, _param1 bson.ObjectId) error {
+	ret := _m.ctrl.Call(_m, "UseRefreshToken", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) UseRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UseRefreshToken", arg0, arg1)
+}
+
+func (_m *MockStore) DeleteRefreshTokenFamily(_param0 context.Context, _param1 bson.ObjectId) error {
+	ret := _m.ctrl.Call(_m, "DeleteRefreshTokenFamily", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) DeleteRefreshTokenFamily(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteRefreshTokenFamily", arg0, arg1)
+}
+
+func (_m *MockStore) CreateSession(_param0 context.Context, _param1 *model.Session) error {
+	ret := _m.ctrl.Call(_m, "CreateSession", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateSession", arg0, arg1)
+}
+
+func (_m *MockStore) GetSessionsByUser(_param0 context.Context, _param1 bson.ObjectId) ([]*model.Session, error) {
+	ret := _m.ctrl.Call(_m, "GetSessionsByUser", _param0, _param1)
+	ret0, _ := ret[0].([]*model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetSessionsByUser(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetSessionsByUser", arg0, arg1)
+}
+
+func (_m *MockStore) DeleteSession(_param0 context.Context, _param1 bson.ObjectId, _param2 bson.ObjectId) error {
+	ret := _m.ctrl.Call(_m, "DeleteSession", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) DeleteSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteSession", arg0, arg1, arg2)
+}
+
+func (_m *MockStore) RevokeToken(_param0 context.Context, _param1 string, _param2 time.Time) error {
+	ret := _m.ctrl.Call(_m, "RevokeToken", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) RevokeToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RevokeToken", arg0, arg1, arg2)
+}
+
+func (_m *MockStore) CreateAccessToken(_param0 context.Context, _param1 *model.AccessToken) error {
+	ret := _m.ctrl.Call(_m, "CreateAccessToken", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) CreateAccessToken(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateAccessToken", arg0, arg1)
+}
+
+func (_m *MockStore) GetAccessTokensByUser(_param0 context.Context, _param1 bson.ObjectId) ([]*model.AccessToken, error) {
+	ret := _m.ctrl.Call(_m, "GetAccessTokensByUser", _param0, _param1)
+	ret0, _ := ret[0].([]*model.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockStoreRecorder) GetAccessTokensByUser(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetAccessTokensByUser", arg0, arg1)
+}
+
+func (_m *MockStore) DeleteAccessToken(_param0 context.Context, _param1 bson.ObjectId, _param2 bson.ObjectId) error {
+	ret := _m.ctrl.Call(_m, "DeleteAccessToken", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockStoreRecorder) DeleteAccessToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteAccessToken", arg0, arg1, arg2)
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/pkg/errors"
+)
+
+// LoginThrottleWindow is a time without failures to forget them
+const LoginThrottleWindow = 24 * time.Hour
+
+const (
+	accountThrottlePrefix = "email:"
+	ipThrottlePrefix      = "ip:"
+	verifyThrottlePrefix  = "verify:"
+)
+
+// AccountThrottleKey of failed logins counter by email
+func AccountThrottleKey(email string) string {
+	return accountThrottlePrefix + strings.ToLower(email)
+}
+
+// verifyThrottleKey of verification requests counter by email
+func verifyThrottleKey(email string) string {
+	return verifyThrottlePrefix + strings.ToLower(email)
+}
+
+// ipThrottleKey of failed logins counter by client address
+//
+// Require middleware.RealIP to count by address of client behind proxy
+func ipThrottleKey(r *http.Request) string {
+	return ipThrottlePrefix + clientIP(r)
+}
+
+// loginDelay after failures, which is doubled by every failure
+// after free attempts and limited by cfg.LoginLockout
+func loginDelay(cfg *config.Config, key string, failures int) time.Duration {
+	free := cfg.LoginAttempts
+	if strings.HasPrefix(key, ipThrottlePrefix) {
+		free = cfg.LoginIPAttempts
+	}
+	if failures < free {
+		return 0
+	}
+	max := time.Duration(cfg.LoginLockout) * time.Second
+	if n := uint(failures - free); n < 32 && time.Second<<n < max {
+		return time.Second << n
+	}
+
+	return max
+}
+
+// ReserveAttempt count login attempt of account from client address
+// before credentials are checked and return time until login is locked
+//
+// Counters are checked without change first, so attempts to locked
+// account don't restart the delay. Then attempt is counted and lockout
+// is checked again by counters before increment, which are returned
+// atomically, so concurrent attempts can't pass it together. Reserved
+// attempt stays counted as failed until ReleaseAttempt.
+func ReserveAttempt(ctx context.Context, cfg *config.Config, store ThrottleStore, email string, r *http.Request) (time.Duration, error) {
+	keys := throttleKeys(email, r)
+	ltl, err := store.GetLoginThrottles(ctx, keys)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	now := time.Now()
+	if lock := loginLock(cfg, ltl, now); lock > 0 {
+		return lock, nil
+	}
+	reserved := make([]*model.LoginThrottle, 0, len(keys))
+	for _, key := range keys {
+		lt, err := store.FailLogin(ctx, key, now, now.Add(LoginThrottleWindow))
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		// State of new counter is empty
+		lt.Key = key
+		reserved = append(reserved, lt)
+	}
+	lock := loginLock(cfg, reserved, now)
+	if lock > 0 {
+		if err := ReleaseAttempt(ctx, store, email, r); err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+
+	return lock, nil
+}
+
+// loginLock return time until login is locked by counters
+func loginLock(cfg *config.Config, ltl []*model.LoginThrottle, now time.Time) time.Duration {
+	var lock time.Duration
+	for _, lt := range ltl {
+		if d := lt.LastFailure.Add(loginDelay(cfg, lt.Key, lt.Failures)).Sub(now); d > lock {
+			lock = d
+		}
+	}
+
+	return lock
+}
+
+// ReleaseAttempt uncount attempt reserved by ReserveAttempt,
+// when credentials are valid
+func ReleaseAttempt(ctx context.Context, store ThrottleStore, email string, r *http.Request) error {
+	for _, key := range throttleKeys(email, r) {
+		if err := store.ReleaseLogin(ctx, key); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// throttleKeys of login attempts of account from client address
+func throttleKeys(email string, r *http.Request) []string {
+	return []string{AccountThrottleKey(email), ipThrottleKey(r)}
+}
+
+// resetLogin forget failed logins of account after successful one
+//
+// Client address counter is kept, so one known password can't be used
+// to continue guessing of others
+func resetLogin(ctx context.Context, store ThrottleStore, email string) error {
+	return errors.WithStack(store.DeleteLoginThrottles(ctx, []string{AccountThrottleKey(email)}))
+}
+
+// WriteLocked response with Retry-After header
+func WriteLocked(w http.ResponseWriter, lock time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int((lock+time.Second-1)/time.Second)))
+	http.Error(w, "too many failed attempts, try later", http.StatusTooManyRequests)
+}
@@ -0,0 +1,270 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// expectAttempt of login from test address, throttle locks the account,
+// so attempt is counted only without it
+func expectAttempt(store *MockStore, email string, throttle *model.LoginThrottle) {
+	ltl := []*model.LoginThrottle{}
+	if throttle != nil {
+		ltl = append(ltl, throttle)
+	}
+	store.EXPECT().
+		GetLoginThrottles(gomock.Any(), []string{auth.AccountThrottleKey(email), "ip:192.0.2.1"}).
+		Return(ltl, nil)
+	if throttle == nil {
+		store.EXPECT().
+			FailLogin(gomock.Any(), auth.AccountThrottleKey(email), gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{}, nil)
+		store.EXPECT().
+			FailLogin(gomock.Any(), "ip:192.0.2.1", gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{}, nil)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	passhash, err := bcrypt.GenerateFromPassword([]byte("secret"), config.Default().BcryptCost)
+	require.NoError(t, err)
+	now := time.Now()
+	user := &model.User{ID: bson.NewObjectId(), Email: "test@email.com", Passhash: passhash}
+	admin := &model.User{ID: bson.NewObjectId(), Email: "admin@email.com", Passhash: passhash, IsAdmin: true}
+	enrolled := &model.User{ID: bson.NewObjectId(), Email: "totp@email.com", Passhash: passhash, TOTPEnabledAt: &now}
+
+	cases := []struct {
+		name     string
+		user     *model.User
+		throttle *model.LoginThrottle
+		body     string
+		code     int
+		enroll   bool
+	}{
+		{
+			name:     "Locked",
+			user:     user,
+			throttle: &model.LoginThrottle{Key: auth.AccountThrottleKey(user.Email), Failures: 7, LastFailure: time.Now()},
+			body:     `{"email":"test@email.com","password":"secret"}`,
+			code:     http.StatusTooManyRequests,
+		},
+		{
+			name: "Wrong password",
+			user: user,
+			body: `{"email":"test@email.com","password":"wrong"}`,
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "Without 2fa",
+			user: user,
+			body: `{"email":"test@email.com","password":"secret"}`,
+			code: http.StatusOK,
+		},
+		{
+			name:   "Mandatory 2fa",
+			user:   admin,
+			body:   `{"email":"admin@email.com","password":"secret"}`,
+			code:   http.StatusAccepted,
+			enroll: true,
+		},
+		{
+			name: "Enabled 2fa",
+			user: enrolled,
+			body: `{"email":"totp@email.com","password":"secret"}`,
+			code: http.StatusAccepted,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	cfg.TOTPForAdmins = true
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := NewMockStore(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			expectAttempt(store, c.user.Email, c.throttle)
+			if c.code != http.StatusTooManyRequests {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), c.user.Email).
+					Return(c.user, nil)
+			}
+			if c.code != http.StatusUnauthorized && c.code != http.StatusTooManyRequests {
+				// Attempt is released when password is valid
+				store.EXPECT().
+					ReleaseLogin(gomock.Any(), gomock.Any()).
+					Return(nil).
+					Times(2)
+			}
+			switch c.code {
+			case http.StatusOK:
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					DeleteLoginThrottles(gomock.Any(), []string{auth.AccountThrottleKey(c.user.Email)}).
+					Return(nil)
+			}
+
+			handler := auth.Login(cfg, keys, auth.NewBcryptAuthenticator(cfg, store), store)
+			req := httptest.NewRequest("POST", "/login", strings.NewReader(c.body))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			if c.code == http.StatusTooManyRequests {
+				assert.Equal(t, "4", res.Header().Get("Retry-After"))
+			}
+			if c.code == http.StatusAccepted {
+				ch := &auth.Challenge{}
+				require.NoError(t, json.NewDecoder(res.Body).Decode(ch))
+				assert.NotEmpty(t, ch.ChallengeToken)
+				assert.Equal(t, c.enroll, ch.Enroll)
+			}
+		})
+	}
+}
+
+func TestReserveAttempt(t *testing.T) {
+	now := time.Now()
+	cfg := config.Default()
+
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockStore
+		lock  time.Duration
+	}{
+		{
+			name: "Locked",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				expectAttempt(store, "test@email.com", &model.LoginThrottle{
+					Key:         auth.AccountThrottleKey("test@email.com"),
+					Failures:    7,
+					LastFailure: now,
+				})
+
+				return store
+			},
+			lock: 4 * time.Second,
+		},
+		{
+			name: "Free",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				expectAttempt(store, "test@email.com", nil)
+
+				return store
+			},
+		},
+		{
+			name: "Locked concurrently",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetLoginThrottles(gomock.Any(), gomock.Any()).
+					Return([]*model.LoginThrottle{}, nil)
+				store.EXPECT().
+					FailLogin(gomock.Any(), auth.AccountThrottleKey("test@email.com"), gomock.Any(), gomock.Any()).
+					Return(&model.LoginThrottle{Failures: 7, LastFailure: now}, nil)
+				store.EXPECT().
+					FailLogin(gomock.Any(), "ip:192.0.2.1", gomock.Any(), gomock.Any()).
+					Return(&model.LoginThrottle{}, nil)
+				store.EXPECT().
+					ReleaseLogin(gomock.Any(), gomock.Any()).
+					Return(nil).
+					Times(2)
+
+				return store
+			},
+			lock: 4 * time.Second,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/login", nil)
+
+			lock, err := auth.ReserveAttempt(context.Background(), cfg, c.store(mockCtrl), "test@email.com", req)
+			require.NoError(t, err)
+			assert.Equal(t, c.lock, lock.Round(time.Second))
+		})
+	}
+}
+
+func TestLoginDelay(t *testing.T) {
+	cfg := config.Default()
+	account := auth.AccountThrottleKey("test@email.com")
+
+	cases := []struct {
+		key      string
+		failures int
+		delay    time.Duration
+	}{
+		{key: account, failures: 4},
+		{key: account, failures: 5, delay: time.Second},
+		{key: account, failures: 6, delay: 2 * time.Second},
+		{key: account, failures: 8, delay: 8 * time.Second},
+		{key: account, failures: 14, delay: 512 * time.Second},
+		{key: account, failures: 15, delay: 900 * time.Second},
+		{key: account, failures: 100, delay: 900 * time.Second},
+		{key: "ip:192.0.2.1", failures: 19},
+		{key: "ip:192.0.2.1", failures: 20, delay: time.Second},
+		{key: "ip:192.0.2.1", failures: 25, delay: 32 * time.Second},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s %d", c.key, c.failures), func(t *testing.T) {
+			store := NewMockStore(mockCtrl)
+			store.EXPECT().
+				GetLoginThrottles(gomock.Any(), gomock.Any()).
+				Return([]*model.LoginThrottle{{Key: c.key, Failures: c.failures, LastFailure: time.Now()}}, nil)
+			if c.delay == 0 {
+				store.EXPECT().
+					FailLogin(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&model.LoginThrottle{}, nil).
+					Times(2)
+			}
+			req := httptest.NewRequest("POST", "/login", nil)
+
+			lock, err := auth.ReserveAttempt(context.Background(), cfg, store, "test@email.com", req)
+			require.NoError(t, err)
+			assert.Equal(t, c.delay, lock.Round(time.Second))
+		})
+	}
+}
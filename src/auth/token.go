@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// RefreshTokenTTL is a lifetime of refresh token
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// Tokens is a response of successful authentication
+type Tokens struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// IssueTokens create access token and refresh token of family
+//
+// Family of refresh tokens is an id of session
+func IssueTokens(ctx context.Context, keys *Keyring, store SessionStore, u *model.User, family bson.ObjectId) (*Tokens, error) {
+	st, err := createSessionToken(ctx, keys, store, u, family)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	token, hash, err := NewRandomToken()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	now := time.Now()
+	err = store.CreateRefreshToken(ctx, &model.RefreshToken{
+		ID:        bson.NewObjectId(),
+		Hash:      hash,
+		Family:    family,
+		UserID:    u.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &Tokens{
+		AccessToken:  st,
+		RefreshToken: token,
+		ExpiresAt:    now.Add(AccessTokenTTL),
+	}, nil
+}
+
+// Refresh exchange refresh token to new access and refresh tokens
+//
+// Reuse of refresh token revoke all tokens of it's family. Claims are
+// derived from current state of user, so refresh tokens survive changes
+// of permissions, they are removed from store to revoke sessions.
+func Refresh(_ *config.Config, keys *Keyring, store Store) http.HandlerFunc {
+	// Exchange refresh token to new access and refresh tokens
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			RefreshToken string `json:"refreshToken" valid:"required"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		rt, err := store.GetRefreshTokenByHash(ctx, HashToken(rd.RefreshToken))
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if rt.ExpiresAt.Before(time.Now()) {
+			l.Debug("refresh token expired")
+			http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+		if rt.UsedAt == nil {
+			err = store.UseRefreshToken(ctx, rt.ID)
+		} else {
+			err = errors.WithStack(errs.ModelNotFound)
+		}
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Warn("refresh token reused", zap.String("userId", rt.UserID.Hex()))
+			if err = store.DeleteRefreshTokenFamily(ctx, rt.Family); err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+			}
+			http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		u, err := store.GetUserByID(ctx, rt.UserID)
+		if err == nil && u.DeletedAt != nil {
+			err = errors.WithStack(errTokenRevoked)
+		}
+		if err == nil {
+			now := time.Now()
+			err = store.TouchSession(ctx, rt.Family, now, now.Add(RefreshTokenTTL))
+		}
+		if errors.Cause(err) == errs.ModelNotFound || errors.Cause(err) == errTokenRevoked {
+			l.Debug(err.Error(), zap.Error(err))
+			if err = store.DeleteRefreshTokenFamily(ctx, rt.Family); err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+			}
+			http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		tokens, err := IssueTokens(ctx, keys, store, u, rt.Family)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, tokens)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Logout revoke current access token, session and family of refresh token if present
+func Logout(_ *config.Config, store Store) http.HandlerFunc {
+	// Revoke current access token, session and family of refresh token if present
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		c, ok := interactiveClaims(w, r)
+		if !ok {
+			return
+		}
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			RefreshToken string `json:"refreshToken"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if rd.RefreshToken != "" {
+			rt, err := store.GetRefreshTokenByHash(ctx, HashToken(rd.RefreshToken))
+			if err == nil && rt.UserID == c.UserID {
+				err = store.DeleteRefreshTokenFamily(ctx, rt.Family)
+			}
+			if err != nil && errors.Cause(err) != errs.ModelNotFound {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+		}
+		if c.SessionID != "" {
+			err := store.DeleteSession(ctx, c.UserID, c.SessionID)
+			if err != nil && errors.Cause(err) != errs.ModelNotFound {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+		}
+		if err := store.RevokeToken(ctx, c.Id, time.Unix(c.ExpiresAt, 0)); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		l.Sugar().Infof("user <%s> logged out", c.Email)
+		http.Error(w, "logged out", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
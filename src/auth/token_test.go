@@ -0,0 +1,334 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestRefresh(t *testing.T) {
+	u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com", TokenVersion: 1}
+	token := func(used bool, ttl time.Duration) *model.RefreshToken {
+		rt := &model.RefreshToken{
+			ID:        bson.NewObjectId(),
+			Family:    bson.NewObjectId(),
+			UserID:    u.ID,
+			ExpiresAt: time.Now().Add(ttl),
+		}
+		if used {
+			now := time.Now()
+			rt.UsedAt = &now
+		}
+
+		return rt
+	}
+
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockStore
+		code  int
+	}{
+		{
+			name: "Unknown token",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetRefreshTokenByHash(gomock.Any(), gomock.Any()).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "Expired token",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetRefreshTokenByHash(gomock.Any(), gomock.Any()).
+					Return(token(false, -time.Minute), nil)
+
+				return store
+			},
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "Reused token",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				rt := token(true, time.Hour)
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetRefreshTokenByHash(gomock.Any(), gomock.Any()).
+					Return(rt, nil)
+				store.EXPECT().
+					DeleteRefreshTokenFamily(gomock.Any(), rt.Family).
+					Return(nil)
+
+				return store
+			},
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "Deleted user",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				rt := token(false, time.Hour)
+				deleted := time.Now()
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetRefreshTokenByHash(gomock.Any(), gomock.Any()).
+					Return(rt, nil)
+				store.EXPECT().
+					UseRefreshToken(gomock.Any(), rt.ID).
+					Return(nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(&model.User{ID: u.ID, Email: u.Email, DeletedAt: &deleted}, nil)
+				store.EXPECT().
+					DeleteRefreshTokenFamily(gomock.Any(), rt.Family).
+					Return(nil)
+
+				return store
+			},
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "Revoked session",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				rt := token(false, time.Hour)
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetRefreshTokenByHash(gomock.Any(), gomock.Any()).
+					Return(rt, nil)
+				store.EXPECT().
+					UseRefreshToken(gomock.Any(), rt.ID).
+					Return(nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+				store.EXPECT().
+					TouchSession(gomock.Any(), rt.Family, gomock.Any(), gomock.Any()).
+					Return(errs.ModelNotFound)
+				store.EXPECT().
+					DeleteRefreshTokenFamily(gomock.Any(), rt.Family).
+					Return(nil)
+
+				return store
+			},
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "Refreshed",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				rt := token(false, time.Hour)
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetRefreshTokenByHash(gomock.Any(), gomock.Any()).
+					Return(rt, nil)
+				store.EXPECT().
+					UseRefreshToken(gomock.Any(), rt.ID).
+					Return(nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+				store.EXPECT().
+					TouchSession(gomock.Any(), rt.Family, gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, nrt *model.RefreshToken) {
+						require.Equal(t, rt.Family, nrt.Family)
+					}).
+					Return(nil)
+
+				return store
+			},
+			code: http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+
+			handler := auth.Refresh(cfg, keys, store)
+			req := httptest.NewRequest("POST", "/refresh", strings.NewReader(`{"refreshToken":"token"}`))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
+
+func TestWithClaims(t *testing.T) {
+	u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com", TokenVersion: 1}
+
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockClaimsStore
+		code  int
+	}{
+		{
+			name: "Revoked jti",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					IsTokenRevoked(gomock.Any(), gomock.Any()).
+					Return(true, nil)
+
+				return store
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name: "Old version",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					IsTokenRevoked(gomock.Any(), gomock.Any()).
+					Return(false, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(&model.User{ID: u.ID, TokenVersion: 2}, nil)
+
+				return store
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name: "Deleted user",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				now := time.Now()
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					IsTokenRevoked(gomock.Any(), gomock.Any()).
+					Return(false, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(&model.User{ID: u.ID, TokenVersion: 1, DeletedAt: &now}, nil)
+
+				return store
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name: "Valid",
+			store: func(ctrl *gomock.Controller) *MockClaimsStore {
+				store := NewMockClaimsStore(ctrl)
+				store.EXPECT().
+					IsTokenRevoked(gomock.Any(), gomock.Any()).
+					Return(false, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+
+				return store
+			},
+			code: http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+	st, err := auth.CreateToken(context.Background(), keys, nil, u)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+
+			handler := auth.WithClaims(cfg, keys, store, true, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, ok := auth.ClaimsFromContext(r.Context())
+				require.True(t, ok)
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req = req.WithContext(opentracing.ContextWithSpan(req.Context(), opentracing.NoopTracer{}.StartSpan("test")))
+			req.Header.Set("Authorization", "Bearer "+st)
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
+
+func TestWithClaimsAudience(t *testing.T) {
+	u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com", TokenVersion: 1}
+
+	cases := []struct {
+		audience string
+		code     int
+	}{
+		{audience: "", code: http.StatusOK},
+		{audience: "access", code: http.StatusOK},
+		{audience: "invite", code: http.StatusForbidden},
+		{audience: "2fa", code: http.StatusForbidden},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.audience, func(t *testing.T) {
+			store := NewMockClaimsStore(mockCtrl)
+			if c.code == http.StatusOK {
+				store.EXPECT().
+					IsTokenRevoked(gomock.Any(), gomock.Any()).
+					Return(false, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+			}
+			claims := &auth.Claims{UserID: u.ID, Email: u.Email, Version: u.TokenVersion}
+			claims.Id = bson.NewObjectId().Hex()
+			claims.Audience = c.audience
+			claims.ExpiresAt = time.Now().Add(time.Minute).Unix()
+			st, err := keys.Sign(claims)
+			require.NoError(t, err)
+
+			handler := auth.WithClaims(cfg, keys, store, true, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req = req.WithContext(opentracing.ContextWithSpan(req.Context(), opentracing.NoopTracer{}.StartSpan("test")))
+			req.Header.Set("Authorization", "Bearer "+st)
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
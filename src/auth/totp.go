@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// totpPeriod is a time step of TOTP code in seconds
+	totpPeriod = 30
+	// totpDigits is a length of TOTP code
+	totpDigits = 6
+	// totpSkew is a count of steps before and after current one
+	// to accept code from not synchronized clock
+	totpSkew = 1
+	// recoveryCodeCount issued on enrolment
+	recoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newTOTPSecret return random base32 encoded secret
+func newTOTPSecret() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return totpEncoding.EncodeToString(buf), nil
+}
+
+// totpURI return otpauth uri of secret to show as qr code
+func totpURI(issuer, email, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + email,
+		RawQuery: q.Encode(),
+	}).String()
+}
+
+// totpCode of key for time step (RFC 6238 with HMAC-SHA1)
+func totpCode(key []byte, step int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+	h := hmac.New(sha1.New, key)
+	h.Write(msg)
+	sum := h.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	v := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, v%uint32(math.Pow10(totpDigits)))
+}
+
+// validateTOTP check code of secret at time and return it's time step
+func validateTOTP(secret, code string, at time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	now := at.Unix() / totpPeriod
+	for step := now - totpSkew; step <= now+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// newRecoveryCodes return recovery codes to show once and hashes of them to store
+func newRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([][]byte, recoveryCodeCount)
+	buf := make([]byte, 5)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(buf))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignoring case and dashes
+func hashRecoveryCode(code string) []byte {
+	code = strings.ToLower(strings.Replace(strings.TrimSpace(code), "-", "", -1))
+
+	return HashToken(code)
+}
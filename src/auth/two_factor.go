@@ -0,0 +1,500 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// ChallengeTokenTTL is a time to pass second factor after login
+	ChallengeTokenTTL = 5 * time.Minute
+
+	challengeAudience = "2fa"
+)
+
+// ChallengeClaims of token exchanged to access token by second factor
+type ChallengeClaims struct {
+	jwt.StandardClaims
+	Version int `json:"ver"`
+}
+
+// Challenge is returned by login instead of tokens if second factor is required
+//
+// Enroll is true for user, which must enroll TOTP before login
+type Challenge struct {
+	ChallengeToken string    `json:"challengeToken"`
+	Enroll         bool      `json:"enroll"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+// requireSecondFactor return true if user enabled TOTP or it is mandatory for user
+func requireSecondFactor(cfg *config.Config, u *model.User) bool {
+	return u.TOTPEnabledAt != nil || cfg.TOTPForAdmins && u.IsAdmin
+}
+
+// login return tokens of authenticated user or second factor challenge
+//
+// Response status is http.StatusAccepted for challenge. Return
+// ErrEmailNotVerified if login of user is blocked by config.UnverifiedPolicy
+func login(ctx context.Context, cfg *config.Config, keys *Keyring, store Store, r *http.Request, u *model.User) (interface{}, int, error) {
+	if u.EmailVerifiedAt == nil && blockUnverified(cfg, u.IsAdmin) {
+		return nil, 0, errors.WithStack(ErrEmailNotVerified)
+	}
+	if !requireSecondFactor(cfg, u) {
+		tokens, err := startSession(ctx, keys, store, r, u)
+		if err != nil {
+			return nil, 0, errors.WithStack(err)
+		}
+
+		return tokens, http.StatusOK, nil
+	}
+
+	now := time.Now()
+	c := &ChallengeClaims{Version: u.TokenVersion}
+	c.Audience = challengeAudience
+	c.Subject = u.ID.Hex()
+	c.IssuedAt = now.Unix()
+	c.ExpiresAt = now.Add(ChallengeTokenTTL).Unix()
+	st, err := keys.Sign(c)
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	return &Challenge{
+		ChallengeToken: st,
+		Enroll:         u.TOTPEnabledAt == nil,
+		ExpiresAt:      now.Add(ChallengeTokenTTL),
+	}, http.StatusAccepted, nil
+}
+
+// challengeUser return not deleted user of valid challenge token
+func challengeUser(ctx context.Context, keys *Keyring, store ClaimsStore, tokenString string) (*model.User, error) {
+	token, err := keys.Parse(tokenString, &ChallengeClaims{})
+	if err != nil {
+		return nil, errors.WithMessage(errInvalidToken, err.Error())
+	}
+	c := token.Claims.(*ChallengeClaims)
+	if !token.Valid || c.Audience != challengeAudience || !bson.IsObjectIdHex(c.Subject) {
+		return nil, errors.WithStack(errInvalidToken)
+	}
+	u, err := store.GetUserByID(ctx, bson.ObjectIdHex(c.Subject))
+	if errors.Cause(err) == errs.ModelNotFound {
+		return nil, errors.WithMessage(errTokenRevoked, err.Error())
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if u.DeletedAt != nil || u.TokenVersion != c.Version {
+		return nil, errors.WithStack(errTokenRevoked)
+	}
+
+	return u, nil
+}
+
+// checkSecondFactor of user with enabled TOTP by code or recovery code
+//
+// Used code can't be used again
+func checkSecondFactor(ctx context.Context, store Store, u *model.User, code, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		err := store.UseUserRecoveryCode(ctx, u.ID, hashRecoveryCode(recoveryCode))
+		if errors.Cause(err) == errs.ModelNotFound {
+			return false, nil
+		}
+
+		return err == nil, errors.WithStack(err)
+	}
+	step, ok := validateTOTP(u.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	err := store.UseUserTOTPStep(ctx, u.ID, step)
+	if errors.Cause(err) == errs.ModelNotFound {
+		return false, nil
+	}
+
+	return err == nil, errors.WithStack(err)
+}
+
+// failSecondFactor write response to failed attempt, which is left
+// counted as failed login by ReserveAttempt
+func failSecondFactor(w http.ResponseWriter, r *http.Request, store Store, u *model.User) {
+	AuditAs(r.Context(), store, r, u.ID, "login.2fa.fail", u.ID.Hex(), nil, nil)
+	http.Error(w, "invalid code", http.StatusUnauthorized)
+}
+
+// TwoFactor exchange challenge token and second factor to tokens
+//
+// User enrolling TOTP by challenge enable it with first valid code
+// and get recovery codes
+func TwoFactor(cfg *config.Config, keys *Keyring, store Store) http.HandlerFunc {
+	// Exchange challenge token and second factor to tokens
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			ChallengeToken string `json:"challengeToken" valid:"required"`
+			Code           string `json:"code" valid:"numeric"`
+			RecoveryCode   string `json:"recoveryCode"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		u, err := challengeUser(ctx, keys, store, rd.ChallengeToken)
+		if isBadToken(err) {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "invalid or expired challenge", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if u.TOTPSecret == "" {
+			l.Debug("totp is not enrolled")
+			http.Error(w, "totp enrolment required", http.StatusBadRequest)
+			return
+		}
+		lock, err := ReserveAttempt(ctx, cfg, store, u.Email, r)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if lock > 0 {
+			l.Sugar().Warnf("login of <%s> is locked for %s", u.Email, lock)
+			WriteLocked(w, lock)
+			return
+		}
+
+		var recoveryCodes []string
+		if u.TOTPEnabledAt == nil {
+			step, ok := validateTOTP(u.TOTPSecret, rd.Code, time.Now())
+			if !ok {
+				l.Debug("invalid totp code")
+				failSecondFactor(w, r, store, u)
+				return
+			}
+			var hashes [][]byte
+			recoveryCodes, hashes, err = newRecoveryCodes()
+			if err == nil {
+				err = store.EnableUserTOTP(ctx, u.ID, step, hashes)
+			}
+		} else {
+			var ok bool
+			ok, err = checkSecondFactor(ctx, store, u, rd.Code, rd.RecoveryCode)
+			if err == nil && !ok {
+				l.Debug("invalid second factor")
+				failSecondFactor(w, r, store, u)
+				return
+			}
+		}
+		if err == nil {
+			err = ReleaseAttempt(ctx, store, u.Email, r)
+		}
+		if err == nil {
+			err = resetLogin(ctx, store, u.Email)
+		}
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		tokens, err := startSession(ctx, keys, store, r, u)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		AuditAs(ctx, store, r, u.ID, "login.2fa", u.ID.Hex(), nil, nil)
+		l.Sugar().Infof("user <%s> passed second factor", u.Email)
+		render.JSON(w, r, &struct {
+			*Tokens
+			RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+		}{tokens, recoveryCodes})
+	}
+	return http.HandlerFunc(fn)
+}
+
+// EnrollTOTP set new pending TOTP secret of current user
+//
+// User required to enroll on login pass challenge token instead of jwt
+func EnrollTOTP(cfg *config.Config, keys *Keyring, store Store) http.HandlerFunc {
+	// Set new pending TOTP secret of current user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			ChallengeToken string `json:"challengeToken"`
+		}{}
+		if err := jd.Decode(&rd); err != nil && err != io.EOF {
+			err = errors.WithMessage(err, "unmarshal")
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var u *model.User
+		var err error
+		if rd.ChallengeToken != "" {
+			u, err = challengeUser(ctx, keys, store, rd.ChallengeToken)
+			if isBadToken(err) {
+				l.Debug(err.Error(), zap.Error(err))
+				http.Error(w, "invalid or expired challenge", http.StatusBadRequest)
+				return
+			}
+		} else {
+			c, ok := interactiveClaims(w, r)
+			if !ok {
+				return
+			}
+			u, err = store.GetUserByID(ctx, c.UserID)
+		}
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if u.TOTPEnabledAt != nil {
+			l.Debug("totp already enabled")
+			http.Error(w, "totp already enabled", http.StatusConflict)
+			return
+		}
+
+		secret, err := newTOTPSecret()
+		if err == nil {
+			err = store.SetUserTOTPSecret(ctx, u.ID, secret)
+		}
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "totp already enabled", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, map[string]string{
+			"secret": secret,
+			"uri":    totpURI(cfg.TOTPIssuer, u.Email, secret),
+		})
+	}
+	return http.HandlerFunc(fn)
+}
+
+// EnableTOTP of current user by first valid code and return recovery codes
+func EnableTOTP(_ *config.Config, store Store) http.HandlerFunc {
+	// Enable TOTP of current user by first valid code
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		c, ok := interactiveClaims(w, r)
+		if !ok {
+			return
+		}
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Code string `json:"code" valid:"numeric,required"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		u, err := store.GetUserByID(ctx, c.UserID)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if u.TOTPEnabledAt != nil {
+			l.Debug("totp already enabled")
+			http.Error(w, "totp already enabled", http.StatusConflict)
+			return
+		}
+		if u.TOTPSecret == "" {
+			l.Debug("totp is not enrolled")
+			http.Error(w, "totp enrolment required", http.StatusBadRequest)
+			return
+		}
+		step, ok := validateTOTP(u.TOTPSecret, rd.Code, time.Now())
+		if !ok {
+			l.Debug("invalid totp code")
+			http.Error(w, "invalid code", http.StatusBadRequest)
+			return
+		}
+		codes, hashes, err := newRecoveryCodes()
+		if err == nil {
+			err = store.EnableUserTOTP(ctx, u.ID, step, hashes)
+		}
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		Audit(ctx, store, r, "totp.enable", u.ID.Hex(), nil, nil)
+		l.Sugar().Infof("user <%s> enabled totp", u.Email)
+		render.JSON(w, r, map[string][]string{"recoveryCodes": codes})
+	}
+	return http.HandlerFunc(fn)
+}
+
+// secondFactorUser return current user with enabled TOTP, which passed second factor
+//
+// Attempts are throttled as logins of user. Response is written
+// and nil returned on failure
+func secondFactorUser(w http.ResponseWriter, r *http.Request, cfg *config.Config, store Store) *model.User {
+	ctx := r.Context()
+	l := tracing.Logger(ctx)
+
+	c, ok := interactiveClaims(w, r)
+	if !ok {
+		return nil
+	}
+
+	jd := json.NewDecoder(r.Body)
+
+	rd := &struct {
+		Code         string `json:"code" valid:"numeric"`
+		RecoveryCode string `json:"recoveryCode"`
+	}{}
+	if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+		l.Debug(err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	if ok, err := govalidator.ValidateStruct(rd); !ok {
+		l.Debug(err.Error())
+		http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+		return nil
+	}
+	u, err := store.GetUserByID(ctx, c.UserID)
+	if err != nil {
+		l.Error(err.Error(), errs.ZapStack(err))
+		http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+		return nil
+	}
+	if u.TOTPEnabledAt == nil {
+		l.Debug("totp is not enabled")
+		http.Error(w, "totp is not enabled", http.StatusBadRequest)
+		return nil
+	}
+	lock, err := ReserveAttempt(ctx, cfg, store, u.Email, r)
+	if err != nil {
+		l.Error(err.Error(), errs.ZapStack(err))
+		http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+		return nil
+	}
+	if lock > 0 {
+		l.Sugar().Warnf("second factor of <%s> is locked for %s", u.Email, lock)
+		WriteLocked(w, lock)
+		return nil
+	}
+	ok, err = checkSecondFactor(ctx, store, u, rd.Code, rd.RecoveryCode)
+	if err == nil && ok {
+		err = ReleaseAttempt(ctx, store, u.Email, r)
+	}
+	if err != nil {
+		l.Error(err.Error(), errs.ZapStack(err))
+		http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+		return nil
+	}
+	if !ok {
+		l.Debug("invalid second factor")
+		AuditAs(ctx, store, r, u.ID, "totp.fail", u.ID.Hex(), nil, nil)
+		http.Error(w, "invalid code", http.StatusBadRequest)
+		return nil
+	}
+
+	return u
+}
+
+// DisableTOTP of current user by valid code or recovery code
+//
+// Admins can't disable TOTP if it is mandatory for them
+func DisableTOTP(cfg *config.Config, store Store) http.HandlerFunc {
+	// Disable TOTP of current user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		if c, ok := ClaimsFromContext(ctx); ok && cfg.TOTPForAdmins && c.IsAdmin {
+			l.Debug("totp is mandatory for admin")
+			http.Error(w, "totp is mandatory for admins", http.StatusForbidden)
+			return
+		}
+		u := secondFactorUser(w, r, cfg, store)
+		if u == nil {
+			return
+		}
+		if err := store.DisableUserTOTP(ctx, u.ID); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		Audit(ctx, store, r, "totp.disable", u.ID.Hex(), nil, nil)
+		l.Sugar().Infof("user <%s> disabled totp", u.Email)
+		http.Error(w, "totp disabled", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// RecoveryCodes replace recovery codes of current user
+func RecoveryCodes(cfg *config.Config, store Store) http.HandlerFunc {
+	// Replace recovery codes of current user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		u := secondFactorUser(w, r, cfg, store)
+		if u == nil {
+			return
+		}
+		codes, hashes, err := newRecoveryCodes()
+		if err == nil {
+			err = store.SetUserRecoveryCodes(ctx, u.ID, hashes)
+		}
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, map[string][]string{"recoveryCodes": codes})
+	}
+	return http.HandlerFunc(fn)
+}
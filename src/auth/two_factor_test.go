@@ -0,0 +1,348 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// authFunc is an Authenticator by function
+type authFunc func(email, password string) (*model.User, error)
+
+func (f authFunc) Authenticate(_ context.Context, email, password string) (*model.User, error) {
+	return f(email, password)
+}
+
+// totp code of base32 secret at time (RFC 6238, 6 digits)
+func totp(t *testing.T, secret string, at time.Time) string {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	require.NoError(t, err)
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(at.Unix()/30))
+	h := hmac.New(sha1.New, key)
+	h.Write(msg)
+	sum := h.Sum(nil)
+	offset := sum[len(sum)-1] & 0xf
+
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:])&0x7fffffff)%1000000)
+}
+
+func TestTOTPVector(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	assert.Equal(t, "287082", totp(t, secret, time.Unix(59, 0)))
+	assert.Equal(t, "005924", totp(t, secret, time.Unix(1234567890, 0)))
+}
+
+func TestTwoFactor(t *testing.T) {
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	now := time.Now()
+	u := &model.User{
+		ID:            bson.NewObjectId(),
+		Email:         "test@email.com",
+		IsAdmin:       true,
+		TOTPSecret:    secret,
+		TOTPEnabledAt: &now,
+	}
+	pending := &model.User{ID: u.ID, Email: u.Email, IsAdmin: true, TOTPSecret: secret}
+
+	cfg := config.Default()
+	cfg.TOTPForAdmins = true
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	// challenge token is issued by login
+	challenge := func(u *model.User) string {
+		authn := authFunc(func(email, password string) (*model.User, error) {
+			return u, nil
+		})
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"email":"test@email.com","password":"secret"}`))
+		store := NewMockStore(gomock.NewController(t))
+		store.EXPECT().
+			GetLoginThrottles(gomock.Any(), gomock.Any()).
+			Return([]*model.LoginThrottle{}, nil)
+		store.EXPECT().
+			FailLogin(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{}, nil).
+			Times(2)
+		store.EXPECT().
+			ReleaseLogin(gomock.Any(), gomock.Any()).
+			Return(nil).
+			Times(2)
+		auth.Login(cfg, keys, authn, store)(res, req)
+		require.Equal(t, http.StatusAccepted, res.Code, res.Body.String())
+		ch := &auth.Challenge{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(ch))
+
+		return ch.ChallengeToken
+	}
+	token := challenge(u)
+
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockStore
+		body  string
+		code  int
+	}{
+		{
+			name: "Bad challenge",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			body: `{"challengeToken":"token","code":"123456"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Wrong code",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+
+				return store
+			},
+			body: `{"challengeToken":"` + token + `","code":"000000"}`,
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "Replayed code",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+				store.EXPECT().
+					UseUserTOTPStep(gomock.Any(), u.ID, gomock.Any()).
+					Return(errs.ModelNotFound)
+
+				return store
+			},
+			body: `{"challengeToken":"` + token + `","code":"` + totp(t, secret, now) + `"}`,
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "Valid code",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+				store.EXPECT().
+					UseUserTOTPStep(gomock.Any(), u.ID, now.Unix()/30).
+					Return(nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					DeleteLoginThrottles(gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			body: `{"challengeToken":"` + token + `","code":"` + totp(t, secret, now) + `"}`,
+			code: http.StatusOK,
+		},
+		{
+			name: "Recovery code",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(u, nil)
+				store.EXPECT().
+					UseUserRecoveryCode(gomock.Any(), u.ID, gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					DeleteLoginThrottles(gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			body: `{"challengeToken":"` + token + `","recoveryCode":"abcd-efgh"}`,
+			code: http.StatusOK,
+		},
+		{
+			name: "Enrolment",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), u.ID).
+					Return(pending, nil)
+				store.EXPECT().
+					EnableUserTOTP(gomock.Any(), u.ID, now.Unix()/30, gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					DeleteLoginThrottles(gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			body: `{"challengeToken":"` + token + `","code":"` + totp(t, secret, now) + `"}`,
+			code: http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			if c.name != "Bad challenge" {
+				store.EXPECT().
+					GetLoginThrottles(gomock.Any(), gomock.Any()).
+					Return([]*model.LoginThrottle{}, nil)
+				store.EXPECT().
+					FailLogin(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&model.LoginThrottle{}, nil).
+					Times(2)
+			}
+			if c.code == http.StatusOK {
+				store.EXPECT().
+					ReleaseLogin(gomock.Any(), gomock.Any()).
+					Return(nil).
+					Times(2)
+			}
+
+			handler := auth.TwoFactor(cfg, keys, store)
+			req := httptest.NewRequest("POST", "/2fa", strings.NewReader(c.body))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			if c.name == "Enrolment" {
+				assert.Contains(t, res.Body.String(), `"recoveryCodes"`)
+			}
+		})
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	now := time.Now()
+	u := &model.User{
+		ID:            bson.NewObjectId(),
+		Email:         "test@email.com",
+		TOTPSecret:    secret,
+		TOTPEnabledAt: &now,
+	}
+
+	cases := []struct {
+		name     string
+		store    func(*gomock.Controller) *MockStore
+		throttle *model.LoginThrottle
+		body     string
+		code     int
+	}{
+		{
+			name: "Locked",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			throttle: &model.LoginThrottle{Key: auth.AccountThrottleKey(u.Email), Failures: 7, LastFailure: now},
+			body:     `{"code":"` + totp(t, secret, now) + `"}`,
+			code:     http.StatusTooManyRequests,
+		},
+		{
+			name: "Wrong recovery code",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					UseUserRecoveryCode(gomock.Any(), u.ID, gomock.Any()).
+					Return(errs.ModelNotFound)
+
+				return store
+			},
+			body: `{"recoveryCode":"abcd-efgh"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Disabled",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					UseUserTOTPStep(gomock.Any(), u.ID, now.Unix()/30).
+					Return(nil)
+				store.EXPECT().
+					ReleaseLogin(gomock.Any(), gomock.Any()).
+					Return(nil).
+					Times(2)
+				store.EXPECT().
+					DisableUserTOTP(gomock.Any(), u.ID).
+					Return(nil)
+
+				return store
+			},
+			body: `{"code":"` + totp(t, secret, now) + `"}`,
+			code: http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			store.EXPECT().
+				GetUserByID(gomock.Any(), u.ID).
+				Return(u, nil)
+			expectAttempt(store, u.Email, c.throttle)
+
+			handler := auth.DisableTOTP(cfg, store)
+			req := httptest.NewRequest("POST", "/2fa/disable", strings.NewReader(c.body))
+			req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: u.ID}))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
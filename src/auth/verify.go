@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/mail"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// EmailTokenTTL is a time while email verification token is valid
+const EmailTokenTTL = 24 * time.Hour
+
+// VerifyResendLimit is a number of verification tokens, which can be
+// requested for email in VerifyResendWindow
+const VerifyResendLimit = 3
+
+// VerifyResendWindow is a time to count verification requests
+const VerifyResendWindow = time.Hour
+
+// ErrEmailNotVerified is a cause of error for login of user
+// with not verified email, when it is blocked by policy
+const ErrEmailNotVerified errs.ConstantError = "email not verified"
+
+// Policies of config.UnverifiedPolicy
+const (
+	// UnverifiedAllow treat users with not verified email as verified
+	UnverifiedAllow = "allow"
+	// UnverifiedReadOnly limit users with not verified email to read permissions
+	UnverifiedReadOnly = "readonly"
+	// UnverifiedBlock deny login of users with not verified email
+	UnverifiedBlock = "block"
+)
+
+// CheckUnverifiedPolicy return error if cfg.UnverifiedPolicy is unknown,
+// so misspelled policy fails startup instead of acting as allow
+func CheckUnverifiedPolicy(cfg *config.Config) error {
+	switch cfg.UnverifiedPolicy {
+	case UnverifiedAllow, UnverifiedReadOnly, UnverifiedBlock:
+		return nil
+	}
+
+	return errors.Errorf("unknown unverified policy %q", cfg.UnverifiedPolicy)
+}
+
+// blockUnverified return true if login of user with not verified email
+// is denied by policy
+func blockUnverified(cfg *config.Config, isAdmin bool) bool {
+	return cfg.UnverifiedPolicy == UnverifiedBlock && !(cfg.ExemptUnverifiedAdmins && isAdmin)
+}
+
+// restrictUnverified limit claims of user with not verified email by policy
+//
+// Return false if claims are not allowed at all
+func restrictUnverified(cfg *config.Config, c *Claims) bool {
+	if !c.Unverified {
+		return true
+	}
+	if blockUnverified(cfg, c.IsAdmin) {
+		return false
+	}
+	if cfg.UnverifiedPolicy == UnverifiedReadOnly {
+		c.IsAdmin = false
+		c.Permission &= model.CanRead | model.CanReadAll
+	}
+
+	return true
+}
+
+// SendVerification send token to verify email, which replace email of user
+// on verification, so it is used both for new accounts and email changes
+func SendVerification(ctx context.Context, store VerifyStore, mailer mail.Mailer, u *model.User, email string) error {
+	token, hash, err := NewRandomToken()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = store.SetUserEmailChange(ctx, u.ID, email, hash, time.Now().Add(EmailTokenTTL)); err != nil {
+		return errors.WithStack(err)
+	}
+	err = mailer.Send(ctx, &mail.Message{
+		To:      email,
+		Subject: "Email verification",
+		Body: fmt.Sprintf(
+			"Use this token to verify your email: %s\n\nIt expires in %s.",
+			token,
+			EmailTokenTTL,
+		),
+	})
+
+	return errors.WithStack(err)
+}
+
+// Verify email by token sent with SendVerification
+func Verify(_ *config.Config, store Store) http.HandlerFunc {
+	// Verify email by token
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Token string `json:"token" valid:"required"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		u, err := store.GetUserByEmailToken(ctx, HashToken(rd.Token))
+		if err == nil {
+			err = store.ConfirmUserEmail(ctx, u.ID, HashToken(rd.Token))
+		}
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "invalid or expired token", http.StatusBadRequest)
+			return
+		} else if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "email already used", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		AuditAs(ctx, store, r, u.ID, "email.verify", u.ID.Hex(), nil, map[string]string{"email": u.PendingEmail})
+		l.Sugar().Infof("email <%s> verified", u.PendingEmail)
+		http.Error(w, "email verified", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// VerificationEmail return pending email of user or not verified email
+// of account, empty if nothing is waiting for verification
+func VerificationEmail(u *model.User) string {
+	if u.PendingEmail != "" {
+		return u.PendingEmail
+	}
+	if u.EmailVerifiedAt == nil && u.InviteNonce == "" {
+		return u.Email
+	}
+
+	return ""
+}
+
+// ResendVerification send new verification token to not verified
+// or pending email of account with email from request
+//
+// It doesn't require login, so user blocked by config.UnverifiedPolicy
+// can request it, answer is the same for unknown or verified emails
+// to not disclose them. Requests are limited to VerifyResendLimit
+// per VerifyResendWindow for every email.
+func ResendVerification(_ *config.Config, store Store, mailer mail.Mailer) http.HandlerFunc {
+	// Send new verification token to email of account
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Email string `json:"email" valid:"email,required"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		now := time.Now()
+		lt, err := store.FailLogin(ctx, verifyThrottleKey(rd.Email), now, now.Add(VerifyResendWindow))
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if lt.Failures >= VerifyResendLimit {
+			l.Sugar().Warnf("verification of <%s> is requested too often", rd.Email)
+			w.Header().Set("Retry-After", strconv.Itoa(int(VerifyResendWindow/time.Second)))
+			http.Error(w, "too many requests, try later", http.StatusTooManyRequests)
+			return
+		}
+		u, err := store.GetUserByEmail(ctx, rd.Email)
+		if errors.Cause(err) == errs.ModelNotFound {
+			// Same answer as for account waiting verification to not disclose registered emails
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "verification token sent", http.StatusAccepted)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		email := VerificationEmail(u)
+		if email == "" {
+			l.Debug("email already verified")
+			http.Error(w, "verification token sent", http.StatusAccepted)
+			return
+		}
+		if err = SendVerification(ctx, store, mailer, u, email); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		l.Sugar().Infof("verification token sent to <%s>", email)
+		http.Error(w, "verification token sent", http.StatusAccepted)
+	}
+	return http.HandlerFunc(fn)
+}
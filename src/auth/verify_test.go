@@ -0,0 +1,390 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestVerify(t *testing.T) {
+	u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com", PendingEmail: "test@email.com"}
+
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockStore
+		code  int
+	}{
+		{
+			name: "Unknown token",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmailToken(gomock.Any(), auth.HashToken("token")).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Email used",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmailToken(gomock.Any(), auth.HashToken("token")).
+					Return(u, nil)
+				store.EXPECT().
+					ConfirmUserEmail(gomock.Any(), u.ID, auth.HashToken("token")).
+					Return(errs.ModelExists)
+
+				return store
+			},
+			code: http.StatusConflict,
+		},
+		{
+			name: "Verified",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByEmailToken(gomock.Any(), auth.HashToken("token")).
+					Return(u, nil)
+				store.EXPECT().
+					ConfirmUserEmail(gomock.Any(), u.ID, auth.HashToken("token")).
+					Return(nil)
+
+				return store
+			},
+			code: http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+
+			handler := auth.Verify(cfg, store)
+			req := httptest.NewRequest("POST", "/verify", strings.NewReader(`{"token":"token"}`))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	verified := time.Now()
+
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockStore
+		code  int
+		sent  int
+	}{
+		{
+			name: "Too often",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					FailLogin(gomock.Any(), "verify:test@email.com", gomock.Any(), gomock.Any()).
+					Return(&model.LoginThrottle{Failures: auth.VerifyResendLimit}, nil)
+
+				return store
+			},
+			code: http.StatusTooManyRequests,
+		},
+		{
+			name: "Unknown email",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					FailLogin(gomock.Any(), "verify:test@email.com", gomock.Any(), gomock.Any()).
+					Return(&model.LoginThrottle{}, nil)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "Test@email.com").
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			code: http.StatusAccepted,
+		},
+		{
+			name: "Already verified",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					FailLogin(gomock.Any(), "verify:test@email.com", gomock.Any(), gomock.Any()).
+					Return(&model.LoginThrottle{}, nil)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "Test@email.com").
+					Return(&model.User{ID: bson.NewObjectId(), Email: "test@email.com", EmailVerifiedAt: &verified}, nil)
+
+				return store
+			},
+			code: http.StatusAccepted,
+		},
+		{
+			name: "Sent",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com", PendingEmail: "test@email.com"}
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					FailLogin(gomock.Any(), "verify:test@email.com", gomock.Any(), gomock.Any()).
+					Return(&model.LoginThrottle{Failures: auth.VerifyResendLimit - 1}, nil)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "Test@email.com").
+					Return(u, nil)
+				store.EXPECT().
+					SetUserEmailChange(gomock.Any(), u.ID, u.PendingEmail, gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			code: http.StatusAccepted,
+			sent: 1,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mailer := &mailerMock{}
+
+			handler := auth.ResendVerification(cfg, c.store(mockCtrl), mailer)
+			req := httptest.NewRequest("POST", "/verify/resend", strings.NewReader(`{"email":"Test@email.com"}`))
+			req = req.WithContext(opentracing.ContextWithSpan(req.Context(), opentracing.NoopTracer{}.StartSpan("test")))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			assert.Len(t, mailer.sent, c.sent)
+		})
+	}
+}
+
+func TestUnverifiedPolicy(t *testing.T) {
+	u := &model.User{
+		ID:         bson.NewObjectId(),
+		Email:      "test@email.com",
+		IsAdmin:    true,
+		Permission: model.CanRead | model.CanEdit,
+	}
+
+	cases := []struct {
+		policy     string
+		code       int
+		permission model.Permission
+	}{
+		{policy: auth.UnverifiedAllow, code: http.StatusOK, permission: model.CanRead | model.CanEdit},
+		{policy: auth.UnverifiedReadOnly, code: http.StatusOK, permission: model.CanRead},
+		{policy: auth.UnverifiedBlock, code: http.StatusForbidden},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	for _, c := range cases {
+		t.Run(c.policy, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.UnverifiedPolicy = c.policy
+			keys, err := auth.NewKeyring(cfg)
+			require.NoError(t, err)
+			st, err := auth.CreateToken(context.Background(), keys, nil, u)
+			require.NoError(t, err)
+			store := NewMockClaimsStore(mockCtrl)
+			store.EXPECT().
+				IsTokenRevoked(gomock.Any(), gomock.Any()).
+				Return(false, nil)
+			store.EXPECT().
+				GetUserByID(gomock.Any(), u.ID).
+				Return(u, nil)
+
+			handler := auth.WithClaims(cfg, keys, store, true, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				cl, ok := auth.ClaimsFromContext(r.Context())
+				require.True(t, ok)
+				assert.True(t, cl.Unverified)
+				assert.Equal(t, c.permission, cl.Permission)
+				assert.Equal(t, c.policy == auth.UnverifiedAllow, cl.IsAdmin)
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req = req.WithContext(opentracing.ContextWithSpan(req.Context(), opentracing.NoopTracer{}.StartSpan("test")))
+			req.Header.Set("Authorization", "Bearer "+st)
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
+
+func TestCheckUnverifiedPolicy(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Default()
+	for _, p := range []string{auth.UnverifiedAllow, auth.UnverifiedReadOnly, auth.UnverifiedBlock} {
+		cfg.UnverifiedPolicy = p
+		assert.NoError(t, auth.CheckUnverifiedPolicy(cfg), p)
+	}
+	for _, p := range []string{"", "read-only", "Block"} {
+		cfg.UnverifiedPolicy = p
+		assert.Error(t, auth.CheckUnverifiedPolicy(cfg), p)
+	}
+}
+
+func TestLoginUnverified(t *testing.T) {
+	u := &model.User{ID: bson.NewObjectId(), Email: "test@email.com"}
+	verified := time.Now()
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	cfg.UnverifiedPolicy = auth.UnverifiedBlock
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range []struct {
+		name   string
+		user   *model.User
+		exempt bool
+		code   int
+	}{
+		{name: "Not verified", user: u, code: http.StatusForbidden},
+		{name: "Verified", user: &model.User{ID: u.ID, Email: u.Email, EmailVerifiedAt: &verified}, code: http.StatusOK},
+		{name: "Admin not exempt", user: &model.User{ID: u.ID, Email: u.Email, IsAdmin: true}, code: http.StatusForbidden},
+		{name: "Admin exempt", user: &model.User{ID: u.ID, Email: u.Email, IsAdmin: true}, exempt: true, code: http.StatusOK},
+		{name: "User not exempt", user: u, exempt: true, code: http.StatusForbidden},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			cfg.ExemptUnverifiedAdmins = c.exempt
+			store := NewMockStore(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			expectAttempt(store, "test@email.com", nil)
+			store.EXPECT().
+				ReleaseLogin(gomock.Any(), gomock.Any()).
+				Return(nil).
+				Times(2)
+			if c.code == http.StatusOK {
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					DeleteLoginThrottles(gomock.Any(), gomock.Any()).
+					Return(nil)
+			}
+			authn := authFunc(func(email, password string) (*model.User, error) {
+				return c.user, nil
+			})
+
+			handler := auth.Login(cfg, keys, authn, store)
+			req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"email":"test@email.com","password":"secret"}`))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	cases := []struct {
+		name  string
+		store func(*gomock.Controller) *MockStore
+		code  int
+		sent  int
+	}{
+		{
+			name: "Users exist",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserCount(gomock.Any()).
+					Return(1, nil)
+
+				return store
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name: "Created",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserCount(gomock.Any()).
+					Return(0, nil)
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, u *model.User) {
+						require.True(t, u.IsAdmin)
+						require.Nil(t, u.EmailVerifiedAt)
+					}).
+					Return(nil)
+				store.EXPECT().
+					CreateAuditEntry(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					SetUserEmailChange(gomock.Any(), gomock.Any(), "admin@email.com", gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			code: http.StatusCreated,
+			sent: 1,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mailer := &mailerMock{}
+			handler := auth.Init(config.Default(), c.store(mockCtrl), mailer)
+			req := httptest.NewRequest("POST", "/init", strings.NewReader(`{"email":"admin@email.com","password":"correct horse"}`))
+			req = req.WithContext(opentracing.ContextWithSpan(req.Context(), opentracing.NoopTracer{}.StartSpan("test")))
+			res := httptest.NewRecorder()
+
+			handler(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			require.Len(t, mailer.sent, c.sent)
+		})
+	}
+}
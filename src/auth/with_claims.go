@@ -4,41 +4,191 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/l10n-center/api/src/config"
 	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
 	"github.com/l10n-center/api/src/tracing"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
+const (
+	// errInvalidToken is a cause of error for malformed, expired or unknown token
+	errInvalidToken errs.ConstantError = "invalid token"
+	// errTokenRevoked is a cause of error for token revoked by jti or version
+	errTokenRevoked errs.ConstantError = "token revoked"
+)
+
+// isBadToken return true if error is caused by token and not by store
+func isBadToken(err error) bool {
+	c := errors.Cause(err)
+
+	return c == errInvalidToken || c == errTokenRevoked || c == ErrEmailNotVerified
+}
+
+// SessionTouchInterval is a minimal interval between updates of session last seen time
+const SessionTouchInterval = time.Minute
+
+// checkClaims return errTokenRevoked if token jti was revoked,
+// user was deleted, user token version was changed or session was removed
+func checkClaims(ctx context.Context, store ClaimsStore, c *Claims) error {
+	revoked, err := store.IsTokenRevoked(ctx, c.Id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if revoked {
+		return errors.WithStack(errTokenRevoked)
+	}
+	u, err := store.GetUserByID(ctx, c.UserID)
+	if errors.Cause(err) == errs.ModelNotFound {
+		return errors.WithStack(errTokenRevoked)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+	if u.DeletedAt != nil || u.TokenVersion != c.Version {
+		return errors.WithStack(errTokenRevoked)
+	}
+	if c.SessionID == "" {
+		return nil
+	}
+	ss, err := store.GetSessionByID(ctx, c.SessionID)
+	if errors.Cause(err) == errs.ModelNotFound || err == nil && ss.UserID != c.UserID {
+		return errors.WithStack(errTokenRevoked)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+	if now := time.Now(); now.Sub(ss.LastSeenAt) > SessionTouchInterval {
+		return errors.WithStack(store.TouchSession(ctx, ss.ID, now, now.Add(RefreshTokenTTL)))
+	}
+
+	return nil
+}
+
+// parseJWT verify signed token and return it's claims
+//
+// Tokens with audience other than access token are rejected,
+// token without audience is an access token issued before audience was set
+func parseJWT(ctx context.Context, keys *Keyring, store ClaimsStore, tokenString string) (*Claims, error) {
+	token, err := keys.Parse(tokenString, &Claims{})
+	if err != nil {
+		return nil, errors.WithMessage(errInvalidToken, err.Error())
+	}
+	c := token.Claims.(*Claims)
+	if !token.Valid || !c.UserID.Valid() || c.Audience != "" && c.Audience != accessAudience {
+		return nil, errors.WithStack(errInvalidToken)
+	}
+	if err := checkClaims(ctx, store, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// parseAccessToken search personal access token, record it's usage
+// and return claims limited by token permission
+func parseAccessToken(ctx context.Context, store ClaimsStore, tokenString string) (*Claims, error) {
+	at, err := store.GetAccessTokenByHash(ctx, HashToken(tokenString))
+	if errors.Cause(err) == errs.ModelNotFound {
+		return nil, errors.WithStack(errInvalidToken)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	now := time.Now()
+	if at.ExpiresAt != nil && at.ExpiresAt.Before(now) {
+		return nil, errors.WithMessage(errInvalidToken, "access token expired")
+	}
+	if at.Service {
+		return parseServiceToken(ctx, store, at, now)
+	}
+	u, err := store.GetUserByID(ctx, at.UserID)
+	if errors.Cause(err) == errs.ModelNotFound || err == nil && u.DeletedAt != nil {
+		return nil, errors.WithStack(errTokenRevoked)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := store.TouchAccessToken(ctx, at.ID, now); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	perm, langs, err := UserPermission(ctx, store, u)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c := &Claims{
+		UserID:     u.ID,
+		Email:      u.Email,
+		Permission: at.Permission & perm,
+		Languages:  langs,
+		Version:    u.TokenVersion,
+		Unverified: u.EmailVerifiedAt == nil,
+		TokenID:    at.ID,
+	}
+	c.Id = at.ID.Hex()
+
+	return c, nil
+}
+
+// parseServiceToken record usage of service account credential
+// and return claims with permission of service account
+func parseServiceToken(ctx context.Context, store ClaimsStore, at *model.AccessToken, now time.Time) (*Claims, error) {
+	sa, err := store.GetServiceAccountByID(ctx, at.UserID)
+	if errors.Cause(err) == errs.ModelNotFound {
+		return nil, errors.WithStack(errTokenRevoked)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := store.TouchAccessToken(ctx, at.ID, now); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c := &Claims{
+		UserID:     sa.ID,
+		Email:      sa.Name,
+		Permission: sa.Permission & model.CanEverything,
+		Languages:  sa.Languages,
+		TokenID:    at.ID,
+		Service:    true,
+	}
+	c.Id = at.ID.Hex()
+
+	return c, nil
+}
+
 // WithClaims middleware check Authorization header and try to parse token
 //
+// Token can be a signed jwt or a personal access token. Claims of personal
+// access token are never admin and have only permissions of token.
+// Claims of user with not verified email are restricted by config.UnverifiedPolicy
+//
 // Require tracing.WithSpan
-func WithClaims(cfg *config.Config, required, onlyAdmin bool) func(http.Handler) http.Handler {
+func WithClaims(cfg *config.Config, keys *Keyring, store ClaimsStore, required, onlyAdmin bool) func(http.Handler) http.Handler {
 	// WithClaims check Authorization header and try to parse token
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			var c *Claims
+			var err error
 			ctx := r.Context()
 			l := tracing.Logger(ctx)
 			authHeader := r.Header.Get("Authorization")
 			if len(authHeader) > 7 && strings.ToUpper(authHeader[:7]) == "BEARER " {
 				tokenString := authHeader[7:]
-				token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-					if t.Method != jwt.SigningMethodHS256 {
-
-						return nil, errors.Errorf("unexpected signing method %q", t.Method)
-					}
-
-					return []byte(cfg.Secret), nil
-				})
-				if err == nil && token.Valid {
-					c = token.Claims.(*Claims)
-					if onlyAdmin {
+				if strings.HasPrefix(tokenString, AccessTokenPrefix) {
+					c, err = parseAccessToken(ctx, store, tokenString)
+				} else {
+					c, err = parseJWT(ctx, keys, store, tokenString)
+				}
+				if err == nil && !restrictUnverified(cfg, c) {
+					err = errors.WithStack(ErrEmailNotVerified)
+				}
+				if err != nil && !isBadToken(err) {
+					l.Error(err.Error(), errs.ZapStack(err))
+					http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+					return
+				}
+				if err == nil {
+					if onlyAdmin && !c.IsAdmin {
 						l.Debug("no admin")
 						http.Error(w, "Forbidden", http.StatusForbidden)
 						return
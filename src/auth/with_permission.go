@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+)
+
+// WithPermission middleware allow request only if claims have any of perm bits
+//
+// Admin is allowed always.
+//
+// Require WithClaims
+func WithPermission(perm model.Permission) func(http.Handler) http.Handler {
+	// WithPermission check permission of claims
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			l := tracing.Logger(r.Context())
+			c, ok := ClaimsFromContext(r.Context())
+			if !ok || !c.IsAdmin && c.Permission&perm == 0 {
+				l.Debug("no permission")
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
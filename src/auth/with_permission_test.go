@@ -0,0 +1,56 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPermission(t *testing.T) {
+	cases := []struct {
+		name   string
+		claims *auth.Claims
+		code   int
+	}{
+		{
+			name: "No claims",
+			code: http.StatusForbidden,
+		},
+		{
+			name:   "Other permission",
+			claims: &auth.Claims{Permission: model.CanRead},
+			code:   http.StatusForbidden,
+		},
+		{
+			name:   "Any of permission",
+			claims: &auth.Claims{Permission: model.CanRead | model.CanDelete},
+			code:   http.StatusOK,
+		},
+		{
+			name:   "Admin",
+			claims: &auth.Claims{IsAdmin: true},
+			code:   http.StatusOK,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			handler := auth.WithPermission(model.CanAppend | model.CanDelete)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest("GET", "/", nil)
+			ctx := opentracing.ContextWithSpan(req.Context(), opentracing.NoopTracer{}.StartSpan("test"))
+			if c.claims != nil {
+				ctx = auth.ContextWithClaims(ctx, c.claims)
+			}
+			res := httptest.NewRecorder()
+
+			handler.ServeHTTP(res, req.WithContext(ctx))
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
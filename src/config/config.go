@@ -18,6 +18,70 @@ type Config struct {
 	MongoHost string `envcfg:"L10NC_MONGO_HOST"`
 	// MongoDB name, default "l10n_center"
 	MongoDB string `envcfg:"L10NC_MONGO_DB"`
+	// MailDir to write outgoing mail, log it if empty
+	MailDir string `envcfg:"L10NC_MAIL_DIR"`
+	// KeysDir with *.pem keys to sign jwt, Secret is used if empty
+	KeysDir string `envcfg:"L10NC_KEYS_DIR"`
+	// SigningKey is a name of key file in KeysDir without extension,
+	// required if KeysDir has many private keys
+	SigningKey string `envcfg:"L10NC_SIGNING_KEY"`
+	// OIDCIssuer url of OpenID Connect provider, single sign-on is disabled if empty
+	OIDCIssuer string `envcfg:"L10NC_OIDC_ISSUER"`
+	// OIDCClientID registered at provider
+	OIDCClientID string `envcfg:"L10NC_OIDC_CLIENT_ID"`
+	// OIDCClientSecret registered at provider, empty for public client
+	OIDCClientSecret string `envcfg:"L10NC_OIDC_CLIENT_SECRET"`
+	// OIDCRedirectURL where provider return authorization code
+	OIDCRedirectURL string `envcfg:"L10NC_OIDC_REDIRECT_URL"`
+	// OIDCCreateUsers create unknown users on first single sign-on
+	OIDCCreateUsers bool `envcfg:"L10NC_OIDC_CREATE_USERS"`
+	// OIDCPermission of users created on single sign-on
+	OIDCPermission int `envcfg:"L10NC_OIDC_PERMISSION"`
+	// LDAPAddr of LDAP server "host:port", users are authenticated
+	// by password hash in store if empty
+	LDAPAddr string `envcfg:"L10NC_LDAP_ADDR"`
+	// LDAPTLS connect to LDAP server over TLS
+	LDAPTLS bool `envcfg:"L10NC_LDAP_TLS"`
+	// LDAPBindDN and LDAPBindPassword of account to search users
+	LDAPBindDN       string `envcfg:"L10NC_LDAP_BIND_DN"`
+	LDAPBindPassword string `envcfg:"L10NC_LDAP_BIND_PASSWORD"`
+	// LDAPBaseDN to search users in
+	LDAPBaseDN string `envcfg:"L10NC_LDAP_BASE_DN"`
+	// LDAPUserFilter to search user by email, default "(mail=%s)"
+	LDAPUserFilter string `envcfg:"L10NC_LDAP_USER_FILTER"`
+	// LDAPGroupAttr of user entry with group DNs, default "memberOf"
+	LDAPGroupAttr string `envcfg:"L10NC_LDAP_GROUP_ATTR"`
+	// LDAPAdminGroup DN which members are admins
+	LDAPAdminGroup string `envcfg:"L10NC_LDAP_ADMIN_GROUP"`
+	// LDAPGroups map group DN to permission mask,
+	// "cn=readers,dc=example,dc=com:2;cn=editors,dc=example,dc=com:14"
+	LDAPGroups string `envcfg:"L10NC_LDAP_GROUPS"`
+	// TOTPIssuer shown in authenticator apps, default "l10n-center"
+	TOTPIssuer string `envcfg:"L10NC_TOTP_ISSUER"`
+	// TOTPForAdmins make two-factor authentication mandatory for admins
+	TOTPForAdmins bool `envcfg:"L10NC_TOTP_FOR_ADMINS"`
+	// LoginAttempts per account before backoff, default 5
+	LoginAttempts int `envcfg:"L10NC_LOGIN_ATTEMPTS"`
+	// LoginIPAttempts per client address before backoff, default 20
+	LoginIPAttempts int `envcfg:"L10NC_LOGIN_IP_ATTEMPTS"`
+	// LoginLockout is a max lockout in seconds, default 900
+	LoginLockout int `envcfg:"L10NC_LOGIN_LOCKOUT"`
+	// PasswordMinLength in characters, default 8
+	PasswordMinLength int `envcfg:"L10NC_PASSWORD_MIN_LENGTH"`
+	// BcryptCost of password hashes, default 10, outdated hashes
+	// are replaced on login
+	BcryptCost int `envcfg:"L10NC_BCRYPT_COST"`
+	// UserRetention is a number of days to keep deleted users before
+	// permanent removal, default 30, 0 to keep forever
+	UserRetention int `envcfg:"L10NC_USER_RETENTION"`
+	// UnverifiedPolicy for users with not verified email, "allow",
+	// "readonly" to limit them to read permissions or "block" to deny
+	// login, default "allow"
+	UnverifiedPolicy string `envcfg:"L10NC_UNVERIFIED_POLICY"`
+	// ExemptUnverifiedAdmins allow admins with not verified email to login
+	// when UnverifiedPolicy is "block", so instance without working mail
+	// is not locked out, default false
+	ExemptUnverifiedAdmins bool `envcfg:"L10NC_EXEMPT_UNVERIFIED_ADMINS"`
 }
 
 // Default Config
@@ -27,6 +91,22 @@ func Default() *Config {
 		Jaeger:    "localhost:5775",
 		MongoHost: "localhost:27017",
 		MongoDB:   "l10n_center",
+
+		LDAPUserFilter: "(mail=%s)",
+		LDAPGroupAttr:  "memberOf",
+
+		TOTPIssuer: "l10n-center",
+
+		LoginAttempts:   5,
+		LoginIPAttempts: 20,
+		LoginLockout:    900,
+
+		PasswordMinLength: 8,
+		BcryptCost:        10,
+
+		UserRetention: 30,
+
+		UnverifiedPolicy: "allow",
 	}
 
 	buf := make([]byte, 15)
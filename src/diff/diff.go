@@ -0,0 +1,142 @@
+// Package diff compare texts word by word
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Op is an operation of change
+type Op string
+
+const (
+	// Equal text is present in both texts
+	Equal Op = "equal"
+	// Insert text is present only in new text
+	Insert Op = "insert"
+	// Delete text is present only in old text
+	Delete Op = "delete"
+)
+
+// Change is a part of text with it's operation
+type Change struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// MaxCells is a maximum size of table of compared words
+//
+// Texts, which differ in more words, are compared as a whole:
+// changed part of old text is deleted and of new one is inserted
+const MaxCells = 1 << 20
+
+// Words return changes between old and new text by words
+//
+// Whitespace between words is compared as a separate word, so concatenation
+// of Equal and Delete changes is an old text and of Equal and Insert is a new one.
+// Neighbour changes with same operation are merged
+func Words(old, new string) []Change {
+	a, b := split(old), split(new)
+
+	// runs of words with same operation are joined in changes on return
+	ops := []Op{}
+	runs := [][]string{}
+	add := func(op Op, words ...string) {
+		if len(words) == 0 {
+			return
+		}
+		if n := len(ops); n > 0 && ops[n-1] == op {
+			runs[n-1] = append(runs[n-1], words...)
+			return
+		}
+		ops = append(ops, op)
+		runs = append(runs, append([]string{}, words...))
+	}
+	changes := func() []Change {
+		result := make([]Change, len(ops))
+		for i, op := range ops {
+			result[i] = Change{Op: op, Text: strings.Join(runs[i], "")}
+		}
+
+		return result
+	}
+
+	// common prefix and suffix don't need comparison table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	add(Equal, a[:prefix]...)
+	common := a[len(a)-suffix:]
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if len(a)*len(b) > MaxCells {
+		add(Delete, a...)
+		add(Insert, b...)
+		add(Equal, common...)
+
+		return changes()
+	}
+
+	// lcs[i][j] is a length of longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			add(Equal, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(Delete, a[i])
+			i++
+		default:
+			add(Insert, b[j])
+			j++
+		}
+	}
+	add(Delete, a[i:]...)
+	add(Insert, b[j:]...)
+	add(Equal, common...)
+
+	return changes()
+}
+
+// split text to words and runs of whitespace
+func split(text string) []string {
+	words := []string{}
+	start := 0
+	prev := false
+	for i, r := range text {
+		space := unicode.IsSpace(r)
+		if i > 0 && space != prev {
+			words = append(words, text[start:i])
+			start = i
+		}
+		prev = space
+	}
+	if start < len(text) {
+		words = append(words, text[start:])
+	}
+
+	return words
+}
@@ -0,0 +1,75 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/l10n-center/api/src/diff"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWords(t *testing.T) {
+	cases := []struct {
+		name    string
+		old     string
+		new     string
+		changes []diff.Change
+	}{
+		{
+			name:    "Empty",
+			changes: []diff.Change{},
+		},
+		{
+			name:    "Equal",
+			old:     "Hello world",
+			new:     "Hello world",
+			changes: []diff.Change{{Op: diff.Equal, Text: "Hello world"}},
+		},
+		{
+			name: "Replace word",
+			old:  "Hello big world",
+			new:  "Hello small world",
+			changes: []diff.Change{
+				{Op: diff.Equal, Text: "Hello "},
+				{Op: diff.Delete, Text: "big"},
+				{Op: diff.Insert, Text: "small"},
+				{Op: diff.Equal, Text: " world"},
+			},
+		},
+		{
+			name: "Append words",
+			old:  "Hallo",
+			new:  "Hallo schöne Welt",
+			changes: []diff.Change{
+				{Op: diff.Equal, Text: "Hallo"},
+				{Op: diff.Insert, Text: " schöne Welt"},
+			},
+		},
+		{
+			name: "From empty",
+			new:  "Bonjour",
+			changes: []diff.Change{
+				{Op: diff.Insert, Text: "Bonjour"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.changes, diff.Words(c.old, c.new))
+		})
+	}
+}
+
+func TestWordsLarge(t *testing.T) {
+	old := "Start " + strings.Repeat("old ", 100000) + "end"
+	new := "Start " + strings.Repeat("new ", 100000) + "end"
+
+	assert.Equal(t, []diff.Change{
+		{Op: diff.Equal, Text: "Start "},
+		{Op: diff.Delete, Text: strings.TrimSpace(strings.Repeat("old ", 100000))},
+		{Op: diff.Insert, Text: strings.TrimSpace(strings.Repeat("new ", 100000))},
+		{Op: diff.Equal, Text: " end"},
+	}, diff.Words(old, new))
+}
@@ -13,4 +13,7 @@ const (
 
 	// ModelNotFound in store
 	ModelNotFound ConstantError = "model not found"
+
+	// ModelExists in store with same unique key
+	ModelExists ConstantError = "model already exists"
 )
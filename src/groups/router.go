@@ -0,0 +1,370 @@
+package groups
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/middleware"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Router return groups section router
+//
+// All routes are available only for admins
+func Router(cfg *config.Config, keys *auth.Keyring, store Store) func(chi.Router) {
+
+	return func(r chi.Router) {
+		r.Use(auth.WithClaims(cfg, keys, store, true, true))
+		r.Use(middleware.JSONOnly)
+
+		r.Get("/", List(cfg, store))
+		r.Post("/", Create(cfg, store))
+		r.Get("/:id", Get(cfg, store))
+		r.Patch("/:id", Update(cfg, store))
+		r.Delete("/:id", Delete(cfg, store))
+		r.Get("/:id/members", Members(cfg, store))
+		r.Put("/:id/members/:userId", AddMember(cfg, store))
+		r.Delete("/:id/members/:userId", RemoveMember(cfg, store))
+	}
+}
+
+// objectID extract object id from url param
+func objectID(r *http.Request, param string) (bson.ObjectId, bool) {
+	id := chi.URLParam(r, param)
+	if !bson.IsObjectIdHex(id) {
+
+		return "", false
+	}
+
+	return bson.ObjectIdHex(id), true
+}
+
+// List of groups
+func List(_ *config.Config, store Store) http.HandlerFunc {
+	// List of groups
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		gl, err := store.GetGroups(ctx)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, gl)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Get group by id
+func Get(_ *config.Config, store Store) http.HandlerFunc {
+	// Get group by id
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad group id")
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		}
+		g, err := store.GetGroupByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, g)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Create new group
+func Create(_ *config.Config, store Store) http.HandlerFunc {
+	// Create new group
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Name       string           `json:"name" valid:"required"`
+			Permission model.Permission `json:"permission"`
+			Languages  []string         `json:"languages"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		languages, err := model.NormalizeLanguages(rd.Languages)
+		if err != nil {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		g := &model.Group{
+			ID:         bson.NewObjectId(),
+			Name:       rd.Name,
+			Permission: rd.Permission & model.CanEverything,
+			Languages:  languages,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+		err = store.CreateGroup(ctx, g)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "group already exists", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "group.create", g.ID.Hex(), nil, g)
+		l.Sugar().Infof("group <%s> created", g.Name)
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, g)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Update group fields present in request
+//
+// Tokens of group members are revoked on update
+func Update(_ *config.Config, store Store) http.HandlerFunc {
+	// Update group fields present in request
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad group id")
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		}
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Name       *string           `json:"name"`
+			Permission *model.Permission `json:"permission"`
+			Languages  *[]string         `json:"languages"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if rd.Name != nil && *rd.Name == "" {
+			l.Debug("empty name")
+			http.Error(w, "validate: name: non zero value required", http.StatusBadRequest)
+			return
+		}
+		g, err := store.GetGroupByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		before := *g
+		if rd.Name != nil {
+			g.Name = *rd.Name
+		}
+		if rd.Permission != nil {
+			g.Permission = *rd.Permission & model.CanEverything
+		}
+		if rd.Languages != nil {
+			g.Languages, err = model.NormalizeLanguages(*rd.Languages)
+			if err != nil {
+				l.Debug(err.Error())
+				http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		g.UpdatedAt = time.Now()
+		err = store.UpdateGroup(ctx, g)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "group already exists", http.StatusConflict)
+			return
+		} else if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "group.update", g.ID.Hex(), &before, g)
+		l.Sugar().Infof("group <%s> updated", g.Name)
+		render.JSON(w, r, g)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Delete group and remove it's members
+func Delete(_ *config.Config, store Store) http.HandlerFunc {
+	// Delete group and remove it's members
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad group id")
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		}
+		err := store.DeleteGroup(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "group.delete", id.Hex(), nil, nil)
+		l.Sugar().Infof("group <%s> deleted", id.Hex())
+		http.Error(w, "group deleted", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Members of group
+func Members(_ *config.Config, store Store) http.HandlerFunc {
+	// Members of group
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad group id")
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		}
+		ul, err := store.GetGroupMembers(ctx, id)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, ul)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// AddMember add user to group
+func AddMember(_ *config.Config, store Store) http.HandlerFunc {
+	// Add user to group
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad group id")
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		}
+		userID, ok := objectID(r, "userId")
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		_, err := store.GetGroupByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		err = store.AddGroupMember(ctx, id, userID)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found or already member", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "group.member.add", id.Hex(), nil, map[string]string{"userId": userID.Hex()})
+		l.Sugar().Infof("user <%s> added to group <%s>", userID.Hex(), id.Hex())
+		http.Error(w, "member added", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// RemoveMember remove user from group
+func RemoveMember(_ *config.Config, store Store) http.HandlerFunc {
+	// Remove user from group
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad group id")
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		}
+		userID, ok := objectID(r, "userId")
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "member not found", http.StatusNotFound)
+			return
+		}
+		err := store.RemoveGroupMember(ctx, id, userID)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "member not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "group.member.remove", id.Hex(), map[string]string{"userId": userID.Hex()}, nil)
+		l.Sugar().Infof("user <%s> removed from group <%s>", userID.Hex(), id.Hex())
+		http.Error(w, "member removed", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,212 @@
+package groups_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/groups"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pressly/chi"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func withNoopSpan(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		sp := opentracing.NoopTracer{}.StartSpan(r.URL.Path)
+		ctx := opentracing.ContextWithSpan(r.Context(), sp)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func TestRouter(t *testing.T) {
+	admin := &model.User{ID: bson.NewObjectId(), Email: "admin@email.com", IsAdmin: true}
+	user := &model.User{ID: bson.NewObjectId(), Email: "user@email.com"}
+	vendor := &model.Group{ID: bson.NewObjectId(), Name: "vendor", Permission: model.CanRead | model.CanEdit}
+
+	cases := []struct {
+		name   string
+		store  func(*gomock.Controller) *MockStore
+		user   *model.User
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{
+			name: "Not admin",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   user,
+			method: "GET",
+			path:   "/groups",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "List",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetGroups(gomock.Any()).
+					Return([]*model.Group{vendor}, nil)
+
+				return store
+			},
+			user:   admin,
+			method: "GET",
+			path:   "/groups",
+			code:   http.StatusOK,
+		},
+		{
+			name: "Create bad language",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/groups",
+			body:   `{"name":"vendor","languages":["de","?"]}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Create",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					CreateGroup(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, g *model.Group) {
+						require.Equal(t, "vendor", g.Name)
+						require.Equal(t, []string{"de", "pt-BR"}, g.Languages)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/groups",
+			body:   `{"name":"vendor","permission":10,"languages":["de","pt-br"]}`,
+			code:   http.StatusCreated,
+		},
+		{
+			name: "Update duplicate",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetGroupByID(gomock.Any(), vendor.ID).
+					Return(&model.Group{ID: vendor.ID, Name: vendor.Name}, nil)
+				store.EXPECT().
+					UpdateGroup(gomock.Any(), gomock.Any()).
+					Return(errs.ModelExists)
+
+				return store
+			},
+			user:   admin,
+			method: "PATCH",
+			path:   "/groups/" + vendor.ID.Hex(),
+			body:   `{"name":"other"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Delete unknown",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					DeleteGroup(gomock.Any(), vendor.ID).
+					Return(errs.ModelNotFound)
+
+				return store
+			},
+			user:   admin,
+			method: "DELETE",
+			path:   "/groups/" + vendor.ID.Hex(),
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Add member",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetGroupByID(gomock.Any(), vendor.ID).
+					Return(vendor, nil)
+				store.EXPECT().
+					AddGroupMember(gomock.Any(), vendor.ID, user.ID).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "PUT",
+			path:   "/groups/" + vendor.ID.Hex() + "/members/" + user.ID.Hex(),
+			body:   `{}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Remove not member",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					RemoveGroupMember(gomock.Any(), vendor.ID, user.ID).
+					Return(errs.ModelNotFound)
+
+				return store
+			},
+			user:   admin,
+			method: "DELETE",
+			path:   "/groups/" + vendor.ID.Hex() + "/members/" + user.ID.Hex(),
+			code:   http.StatusNotFound,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(withNoopSpan)
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			r.Route("/groups", groups.Router(cfg, keys, store))
+
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			if c.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			st, err := auth.CreateToken(context.Background(), keys, store, c.user)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+st)
+			store.EXPECT().
+				IsTokenRevoked(gomock.Any(), gomock.Any()).
+				Return(false, nil)
+			store.EXPECT().
+				GetUserByID(gomock.Any(), c.user.ID).
+				Return(c.user, nil)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package groups
+
+import (
+	"context"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/model"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+//go:generate mockgen -source=store.go -destination=store_mock_test.go -package=groups_test -aux_files=auth=../auth/store.go
+
+// Store is a interface of store required in package groups
+type Store interface {
+	auth.ClaimsStore
+	auth.AuditStore
+	GetGroups(context.Context) ([]*model.Group, error)
+	GetGroupByID(context.Context, bson.ObjectId) (*model.Group, error)
+	CreateGroup(context.Context, *model.Group) error
+	UpdateGroup(context.Context, *model.Group) error
+	DeleteGroup(context.Context, bson.ObjectId) error
+	GetGroupMembers(context.Context, bson.ObjectId) ([]*model.User, error)
+	AddGroupMember(context.Context, bson.ObjectId, bson.ObjectId) error
+	RemoveGroupMember(context.Context, bson.ObjectId, bson.ObjectId) error
+}
@@ -0,0 +1,50 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// FileMailer write every message in separate file in directory
+type FileMailer struct {
+	dir string
+}
+
+// NewFileMailer create directory if not exists and return FileMailer
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &FileMailer{dir}, nil
+}
+
+// Send message to file named by time and recipient
+func (fm *FileMailer) Send(ctx context.Context, m *Message) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "mail:Send")
+
+	defer sp.Finish()
+
+	name := fmt.Sprintf(
+		"%d-%s.eml",
+		time.Now().UnixNano(),
+		strings.Map(func(r rune) rune {
+			if r == '/' || r == os.PathSeparator {
+				return '_'
+			}
+
+			return r
+		}, m.To),
+	)
+	data := fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", m.To, m.Subject, m.Body)
+
+	return errors.WithStack(ioutil.WriteFile(filepath.Join(fm.dir, name), []byte(data), 0600))
+}
@@ -0,0 +1,39 @@
+package mail_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/l10n-center/api/src/mail"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "l10n-center-mail")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	m, err := mail.NewFileMailer(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), &mail.Message{
+		To:      "user@example.com",
+		Subject: "Hello",
+		Body:    "Body",
+	})
+	require.NoError(t, err)
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "out", files[0].Name()))
+	require.NoError(t, err)
+	assert.Equal(t, "To: user@example.com\r\nSubject: Hello\r\n\r\nBody\r\n", string(data))
+}
@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/l10n-center/api/src/config"
+
+	"github.com/pkg/errors"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer deliver messages to users
+type Mailer interface {
+	Send(context.Context, *Message) error
+}
+
+// New Mailer by config
+//
+// Return FileMailer if MailDir configured and LogMailer otherwise
+func New(cfg *config.Config) (Mailer, error) {
+	if cfg.MailDir == "" {
+		return LogMailer{}, nil
+	}
+
+	m, err := NewFileMailer(cfg.MailDir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return m, nil
+}
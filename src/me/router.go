@@ -0,0 +1,258 @@
+package me
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/mail"
+	"github.com/l10n-center/api/src/middleware"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Profile of current user with effective permission and languages
+type Profile struct {
+	*model.User
+	EffectivePermission model.Permission `json:"effectivePermission"`
+	EffectiveLanguages  []string         `json:"effectiveLanguages"`
+}
+
+// Router return profile section router of current user
+func Router(cfg *config.Config, keys *auth.Keyring, store Store, mailer mail.Mailer) func(chi.Router) {
+
+	return func(r chi.Router) {
+		r.Use(auth.WithClaims(cfg, keys, store, true, false))
+		r.Use(middleware.JSONOnly)
+
+		r.Get("/", Get(cfg, store))
+		r.Patch("/", Update(cfg, keys, store, mailer))
+	}
+}
+
+// normalizePreferences validate preferences and bring tags to canonical case
+func normalizePreferences(p *model.Preferences) error {
+	var ok bool
+	if p.Locale != "" {
+		if p.Locale, ok = model.NormalizeLanguage(p.Locale); !ok {
+			return errors.New("locale: invalid language tag")
+		}
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "Local" {
+			return errors.New("timezone: unknown time zone")
+		}
+	}
+	if p.SourceLanguage != "" {
+		if p.SourceLanguage, ok = model.NormalizeLanguage(p.SourceLanguage); !ok {
+			return errors.New("sourceLanguage: invalid language tag")
+		}
+	}
+	langs, err := model.NormalizeLanguages(p.TargetLanguages)
+	if err != nil {
+		return errors.WithMessage(err, "targetLanguages")
+	}
+	p.TargetLanguages = langs
+
+	return nil
+}
+
+// profile of user with effective permission and languages
+func profile(r *http.Request, store Store, u *model.User) (*Profile, error) {
+	perm, langs, err := auth.UserPermission(r.Context(), store, u)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &Profile{User: u, EffectivePermission: perm, EffectiveLanguages: langs}, nil
+}
+
+// Get profile of current user
+func Get(_ *config.Config, store Store) http.HandlerFunc {
+	// Get profile of current user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		c, _ := auth.ClaimsFromContext(ctx)
+		if c.Service {
+			l.Debug("service account has no profile")
+			http.Error(w, "not allowed for service account", http.StatusForbidden)
+			return
+		}
+		u, err := store.GetUserByID(ctx, c.UserID)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		p, err := profile(r, store, u)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, p)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Update profile of current user
+//
+// Change of password or email require current password, which checks
+// are throttled as logins of user. Password change revoke all tokens
+// and other sessions of user, new tokens of current session are returned.
+// New email replace current one only after verification by token sent to it
+func Update(cfg *config.Config, keys *auth.Keyring, store Store, mailer mail.Mailer) http.HandlerFunc {
+	// Update profile of current user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		c, _ := auth.ClaimsFromContext(ctx)
+		if c.TokenID != "" {
+			l.Debug("access token used for profile update")
+			http.Error(w, "not allowed for access token", http.StatusForbidden)
+			return
+		}
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Email           string             `json:"email" valid:"email"`
+			Password        string             `json:"password"`
+			CurrentPassword string             `json:"currentPassword"`
+			Preferences     *model.Preferences `json:"preferences"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		if rd.Preferences != nil {
+			if err := normalizePreferences(rd.Preferences); err != nil {
+				l.Debug(err.Error())
+				http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		u, err := store.GetUserByID(ctx, c.UserID)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if rd.Email == u.Email {
+			rd.Email = ""
+		}
+		if rd.Email != "" || rd.Password != "" {
+			lock, err := auth.ReserveAttempt(ctx, cfg, store, u.Email, r)
+			if err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+			if lock > 0 {
+				l.Sugar().Warnf("password check of <%s> is locked for %s", u.Email, lock)
+				auth.WriteLocked(w, lock)
+				return
+			}
+			if bcrypt.CompareHashAndPassword(u.Passhash, []byte(rd.CurrentPassword)) != nil {
+				l.Debug("invalid current password")
+				auth.Audit(ctx, store, r, "password.fail", u.ID.Hex(), nil, nil)
+				http.Error(w, "invalid current password", http.StatusForbidden)
+				return
+			}
+			if err = auth.ReleaseAttempt(ctx, store, u.Email, r); err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		var passhash []byte
+		if rd.Password != "" {
+			passhash, err = auth.HashPassword(cfg, u.Email, rd.Password)
+			if auth.IsWeakPassword(err) {
+				l.Debug(err.Error())
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			} else if err != nil {
+				l.Error(err.Error())
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+		}
+		if rd.Email != "" {
+			_, err = store.GetUserByEmail(ctx, rd.Email)
+			if err == nil {
+				l.Debug("email already used")
+				http.Error(w, "email already used", http.StatusConflict)
+				return
+			} else if errors.Cause(err) != errs.ModelNotFound {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if rd.Preferences != nil {
+			if err = store.SetUserPreferences(ctx, u.ID, *rd.Preferences); err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+			auth.Audit(ctx, store, r, "preferences.update", u.ID.Hex(), u.Preferences, rd.Preferences)
+			u.Preferences = *rd.Preferences
+		}
+		var tokens *auth.Tokens
+		if passhash != nil {
+			u.TokenVersion, err = store.ChangeUserPasshash(ctx, u.ID, passhash, c.SessionID)
+			if err == nil && c.SessionID != "" {
+				tokens, err = auth.IssueTokens(ctx, keys, store, u, c.SessionID)
+			}
+			if err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+			auth.Audit(ctx, store, r, "password.change", u.ID.Hex(), nil, nil)
+			l.Sugar().Infof("user <%s> changed password", u.Email)
+		}
+		if rd.Email != "" {
+			if err = auth.SendVerification(ctx, store, mailer, u, rd.Email); err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+			auth.Audit(ctx, store, r, "email.change", u.ID.Hex(), nil, map[string]string{"pendingEmail": rd.Email})
+			l.Sugar().Infof("user <%s> requested email change to <%s>", u.Email, rd.Email)
+			u.PendingEmail = rd.Email
+		}
+		p, err := profile(r, store, u)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, &struct {
+			*Profile
+			Tokens *auth.Tokens `json:"tokens,omitempty"`
+		}{p, tokens})
+	}
+	return http.HandlerFunc(fn)
+}
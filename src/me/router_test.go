@@ -0,0 +1,303 @@
+package me_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/mail"
+	"github.com/l10n-center/api/src/me"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pressly/chi"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type mailerMock struct {
+	sent []*mail.Message
+}
+
+func (mm *mailerMock) Send(_ context.Context, m *mail.Message) error {
+	mm.sent = append(mm.sent, m)
+
+	return nil
+}
+
+func withNoopSpan(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		sp := opentracing.NoopTracer{}.StartSpan(r.URL.Path)
+		ctx := opentracing.ContextWithSpan(r.Context(), sp)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// expectAttempt of current password check, which is counted if not locked
+// by failures and released if valid
+func expectAttempt(store *MockStore, failures int, released bool) {
+	store.EXPECT().
+		GetLoginThrottles(gomock.Any(), gomock.Any()).
+		Return([]*model.LoginThrottle{{Key: auth.AccountThrottleKey("user@email.com"), Failures: failures, LastFailure: time.Now()}}, nil)
+	if failures < config.Default().LoginAttempts {
+		store.EXPECT().
+			FailLogin(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&model.LoginThrottle{}, nil).
+			Times(2)
+	}
+	if released {
+		store.EXPECT().
+			ReleaseLogin(gomock.Any(), gomock.Any()).
+			Return(nil).
+			Times(2)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	passhash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &model.User{
+		ID:         bson.NewObjectId(),
+		Email:      "user@email.com",
+		Passhash:   passhash,
+		Permission: model.CanRead,
+		Languages:  []string{"de"},
+	}
+	sid := bson.NewObjectId()
+
+	cases := []struct {
+		name     string
+		store    func(*gomock.Controller) *MockStore
+		method   string
+		path     string
+		body     string
+		code     int
+		contains string
+		sent     int
+		session  bson.ObjectId
+	}{
+		{
+			name: "Get",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			method:   "GET",
+			path:     "/me",
+			code:     http.StatusOK,
+			contains: `"effectiveLanguages":["de"]`,
+		},
+		{
+			name: "Bad timezone",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			method: "PATCH",
+			path:   "/me",
+			body:   `{"preferences":{"timezone":"Mars/Olympus"}}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Preferences",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					SetUserPreferences(gomock.Any(), user.ID, model.Preferences{
+						Locale:          "en-US",
+						Timezone:        "UTC",
+						SourceLanguage:  "en",
+						TargetLanguages: []string{"de", "pt-BR"},
+					}).
+					Return(nil)
+
+				return store
+			},
+			method:   "PATCH",
+			path:     "/me",
+			body:     `{"preferences":{"locale":"en-us","timezone":"UTC","sourceLanguage":"en","targetLanguages":["de","pt-br"]}}`,
+			code:     http.StatusOK,
+			contains: `"locale":"en-US"`,
+		},
+		{
+			name: "Password without current",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				expectAttempt(store, 0, false)
+
+				return store
+			},
+			method: "PATCH",
+			path:   "/me",
+			body:   `{"password":"battery staple"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Current password locked",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				expectAttempt(store, 7, false)
+
+				return store
+			},
+			method: "PATCH",
+			path:   "/me",
+			body:   `{"password":"battery staple","currentPassword":"correct horse"}`,
+			code:   http.StatusTooManyRequests,
+		},
+		{
+			name: "Weak password",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				expectAttempt(store, 0, true)
+
+				return store
+			},
+			method: "PATCH",
+			path:   "/me",
+			body:   `{"password":"short","currentPassword":"correct horse"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Password",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				expectAttempt(store, 0, true)
+				store.EXPECT().
+					ChangeUserPasshash(gomock.Any(), user.ID, gomock.Any(), bson.ObjectId("")).
+					Return(1, nil)
+
+				return store
+			},
+			method: "PATCH",
+			path:   "/me",
+			body:   `{"password":"battery staple","currentPassword":"correct horse"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Password of session",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				expectAttempt(store, 0, true)
+				store.EXPECT().
+					ChangeUserPasshash(gomock.Any(), user.ID, gomock.Any(), sid).
+					Return(2, nil)
+				store.EXPECT().
+					CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, rt *model.RefreshToken) {
+						require.Equal(t, sid, rt.Family)
+					}).
+					Return(nil)
+
+				return store
+			},
+			session:  sid,
+			method:   "PATCH",
+			path:     "/me",
+			body:     `{"password":"battery staple","currentPassword":"correct horse"}`,
+			code:     http.StatusOK,
+			contains: `"refreshToken":"`,
+		},
+		{
+			name: "Email used",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				expectAttempt(store, 0, true)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "other@email.com").
+					Return(&model.User{ID: bson.NewObjectId()}, nil)
+
+				return store
+			},
+			method: "PATCH",
+			path:   "/me",
+			body:   `{"email":"other@email.com","currentPassword":"correct horse"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Email",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				expectAttempt(store, 0, true)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), "new@email.com").
+					Return(nil, errs.ModelNotFound)
+				store.EXPECT().
+					SetUserEmailChange(gomock.Any(), user.ID, "new@email.com", gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			method:   "PATCH",
+			path:     "/me",
+			body:     `{"email":"new@email.com","currentPassword":"correct horse"}`,
+			code:     http.StatusOK,
+			contains: `"pendingEmail":"new@email.com"`,
+			sent:     1,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(withNoopSpan)
+			mailer := &mailerMock{}
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			r.Route("/me", me.Router(cfg, keys, store, mailer))
+
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			if c.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			st, err := auth.CreateToken(context.Background(), keys, store, user)
+			require.NoError(t, err)
+			if c.session != "" {
+				login := NewMockStore(mockCtrl)
+				login.EXPECT().
+					CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+				tokens, err := auth.IssueTokens(context.Background(), keys, login, user, c.session)
+				require.NoError(t, err)
+				st = tokens.AccessToken
+				store.EXPECT().
+					GetSessionByID(gomock.Any(), c.session).
+					Return(&model.Session{ID: c.session, UserID: user.ID, LastSeenAt: time.Now()}, nil)
+			}
+			req.Header.Set("Authorization", "Bearer "+st)
+			store.EXPECT().
+				IsTokenRevoked(gomock.Any(), gomock.Any()).
+				Return(false, nil)
+			store.EXPECT().
+				GetUserByID(gomock.Any(), user.ID).
+				Return(user, nil).
+				AnyTimes()
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			require.Contains(t, res.Body.String(), c.contains)
+			require.Len(t, mailer.sent, c.sent)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package me
+
+import (
+	"context"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/model"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+//go:generate mockgen -source=store.go -destination=store_mock_test.go -package=me_test -aux_files=auth=../auth/store.go
+
+// Store is a interface of store required in package me
+type Store interface {
+	auth.ClaimsStore
+	auth.AuditStore
+	auth.VerifyStore
+	auth.ThrottleStore
+	GetUserByEmail(context.Context, string) (*model.User, error)
+	CreateRefreshToken(context.Context, *model.RefreshToken) error
+	ChangeUserPasshash(context.Context, bson.ObjectId, []byte, bson.ObjectId) (int, error)
+	SetUserPreferences(context.Context, bson.ObjectId, model.Preferences) error
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AccessToken is a personal token for non interactive clients
+//
+// Token is limited by Permission, which is a subset of user permission.
+// Service token is a credential of service account with it's ID as UserID.
+//
+// nolint: aligncheck
+type AccessToken struct {
+	ID         bson.ObjectId `bson:"_id" json:"id"`
+	UserID     bson.ObjectId `bson:"userId" json:"userId"`
+	Name       string        `bson:"name" json:"name"`
+	Hash       []byte        `bson:"hash" json:"-"`
+	Permission Permission    `bson:"permission" json:"permission"`
+	Service    bool          `bson:"service,omitempty" json:"service,omitempty"`
+	CreatedAt  time.Time     `bson:"createdAt" json:"createdAt"`
+	ExpiresAt  *time.Time    `bson:"expiresAt" json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time    `bson:"lastUsedAt" json:"lastUsedAt,omitempty"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AuditEntry is a record of security relevant or data changing action
+//
+// ActorID is empty for anonymous actions, such as failed login.
+// ActorService is set if actor is a service account.
+// Before and After contain only changed fields of target.
+//
+// nolint: aligncheck
+type AuditEntry struct {
+	ID           bson.ObjectId          `bson:"_id" json:"id"`
+	ActorID      bson.ObjectId          `bson:"actorId,omitempty" json:"actorId,omitempty"`
+	ActorService bool                   `bson:"actorService,omitempty" json:"actorService,omitempty"`
+	Action       string                 `bson:"action" json:"action"`
+	Target       string                 `bson:"target" json:"target"`
+	IP           string                 `bson:"ip" json:"ip"`
+	TraceID      string                 `bson:"traceId" json:"traceId"`
+	Before       map[string]interface{} `bson:"before,omitempty" json:"before,omitempty"`
+	After        map[string]interface{} `bson:"after,omitempty" json:"after,omitempty"`
+	CreatedAt    time.Time              `bson:"createdAt" json:"createdAt"`
+}
+
+// AuditQuery is a filter of audit entries
+//
+// Empty fields are not filtered. Cursor is an ID of last entry of previous
+// page, entries are returned from newest to oldest
+type AuditQuery struct {
+	ActorID bson.ObjectId
+	Action  string
+	From    *time.Time
+	To      *time.Time
+	Cursor  bson.ObjectId
+	Limit   int
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Group of users, such as vendor team, which members inherit
+// Permission and Languages of group
+//
+// Members refer to group by ID in User.Groups.
+//
+// nolint: aligncheck
+type Group struct {
+	ID         bson.ObjectId `bson:"_id" json:"id"`
+	Name       string        `bson:"name" json:"name"`
+	Permission Permission    `bson:"permission" json:"permission"`
+	Languages  []string      `bson:"languages,omitempty" json:"languages,omitempty"`
+	CreatedAt  time.Time     `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time     `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeLanguage check syntax of BCP 47 language tag and return it
+// in canonical case: language in lower case, script in title case
+// and region in upper case, e.g. "zh-Hant-TW"
+func NormalizeLanguage(tag string) (string, bool) {
+	parts := strings.Split(tag, "-")
+	if l := len(parts[0]); l < 2 || l > 8 || !isAlnum(parts[0], false) {
+		return "", false
+	}
+	parts[0] = strings.ToLower(parts[0])
+	for i, p := range parts[1:] {
+		if len(p) < 1 || len(p) > 8 || !isAlnum(p, true) {
+			return "", false
+		}
+		switch {
+		case len(p) == 4 && isAlnum(p, false):
+			parts[i+1] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		case len(p) == 2 && isAlnum(p, false):
+			parts[i+1] = strings.ToUpper(p)
+		default:
+			parts[i+1] = strings.ToLower(p)
+		}
+	}
+
+	return strings.Join(parts, "-"), true
+}
+
+// NormalizeLanguages normalize every tag and remove duplicates
+func NormalizeLanguages(tags []string) ([]string, error) {
+	languages := make([]string, 0, len(tags))
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		lang, ok := NormalizeLanguage(tag)
+		if !ok {
+			return nil, fmt.Errorf("bad language tag: %s", tag)
+		}
+		if !seen[lang] {
+			seen[lang] = true
+			languages = append(languages, lang)
+		}
+	}
+
+	return languages, nil
+}
+
+// MatchLanguage return true if tag is equal to binded language
+// or is it's more specific variant, e.g. "pt-BR" match "pt"
+func MatchLanguage(binded, tag string) bool {
+	if len(tag) < len(binded) || !strings.EqualFold(tag[:len(binded)], binded) {
+		return false
+	}
+
+	return len(tag) == len(binded) || tag[len(binded)] == '-'
+}
+
+// isAlnum return true if s contain only ASCII letters and, if digits, numbers
+func isAlnum(s string, digits bool) bool {
+	for _, r := range s {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || digits && '0' <= r && r <= '9') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+)
+
+// LoginThrottle count failed logins by account or by client address
+//
+// Key is "email:<email>" or "ip:<address>", counter expires at ExpiresAt.
+// Same counters with "verify:<email>" keys limit verification requests.
+type LoginThrottle struct {
+	Key         string    `bson:"_id" json:"key"`
+	Failures    int       `bson:"failures" json:"failures"`
+	LastFailure time.Time `bson:"lastFailure" json:"lastFailure"`
+	ExpiresAt   time.Time `bson:"expiresAt" json:"expiresAt"`
+}
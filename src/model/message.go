@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Message is a source string of project in it's source language
+//
+// Key is unique in project together with Context, which distinguish
+// same keys with different meaning. MaxLength limit length of translations
+// in characters if not zero.
+//
+// nolint: aligncheck
+type Message struct {
+	ID          bson.ObjectId `bson:"_id" json:"id"`
+	ProjectID   bson.ObjectId `bson:"projectId" json:"projectId"`
+	Key         string        `bson:"key" json:"key"`
+	Context     string        `bson:"context" json:"context,omitempty"`
+	Text        string        `bson:"text" json:"text"`
+	Description string        `bson:"description,omitempty" json:"description,omitempty"`
+	MaxLength   int           `bson:"maxLength,omitempty" json:"maxLength,omitempty"`
+	Tags        []string      `bson:"tags,omitempty" json:"tags,omitempty"`
+	CreatedAt   time.Time     `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time     `bson:"updatedAt" json:"updatedAt"`
+}
+
+// MessageQuery is a filter of project messages
+//
+// Empty fields are not filtered. KeyPrefix match beginning of key and
+// Search match substring of key or text ignoring case. Cursor is an ID
+// of last message of previous page, messages are returned in order of creation
+type MessageQuery struct {
+	ProjectID bson.ObjectId
+	KeyPrefix string
+	Context   *string
+	Tag       string
+	Search    string
+	Cursor    bson.ObjectId
+	Limit     int
+}
@@ -16,7 +16,9 @@ const (
 	CanAppend
 	// CanDelete messages
 	CanDelete
+	// CanReview translations on readable languages
+	CanReview
 
 	// CanEverything is a total access
-	CanEverything = CanRead | CanReadAll | CanEdit | CanEditAll | CanAppend | CanDelete
+	CanEverything = CanRead | CanReadAll | CanEdit | CanEditAll | CanAppend | CanDelete | CanReview
 )
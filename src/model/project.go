@@ -0,0 +1,41 @@
+package model
+
+import (
+	"regexp"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// slugRe is a syntax of project slug: lower case words divided by hyphen
+var slugRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// MaxSlugLength is a maximum length of project slug
+const MaxSlugLength = 64
+
+// Project is a localized product with messages in SourceLanguage,
+// which are translated to TargetLanguages
+//
+// Archived project is read only and hidden from default listing.
+// Export of project with ApprovedOnly contain only approved translations.
+//
+// nolint: aligncheck
+type Project struct {
+	ID              bson.ObjectId `bson:"_id" json:"id"`
+	Name            string        `bson:"name" json:"name"`
+	Slug            string        `bson:"slug" json:"slug"`
+	Description     string        `bson:"description,omitempty" json:"description,omitempty"`
+	SourceLanguage  string        `bson:"sourceLanguage" json:"sourceLanguage"`
+	TargetLanguages []string      `bson:"targetLanguages" json:"targetLanguages"`
+	OwnerID         bson.ObjectId `bson:"ownerId" json:"ownerId"`
+	ApprovedOnly    bool          `bson:"approvedOnly" json:"approvedOnly"`
+	ArchivedAt      *time.Time    `bson:"archivedAt,omitempty" json:"archivedAt,omitempty"`
+	CreatedAt       time.Time     `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time     `bson:"updatedAt" json:"updatedAt"`
+}
+
+// ValidSlug return true if slug contain only lower case letters, digits
+// and single hyphens between them and is not longer than MaxSlugLength
+func ValidSlug(slug string) bool {
+	return len(slug) <= MaxSlugLength && slugRe.MatchString(slug)
+}
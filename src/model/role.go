@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+// Role is a named Permission mask assigned to users
+//
+// Role is referenced from users by Name, so it can't be renamed
+//
+// nolint: aligncheck
+type Role struct {
+	Name        string     `bson:"_id" json:"name"`
+	Description string     `bson:"description" json:"description"`
+	Permission  Permission `bson:"permission" json:"permission"`
+	CreatedAt   time.Time  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time  `bson:"updatedAt" json:"updatedAt"`
+}
+
+// DefaultRoles are created on first start
+var DefaultRoles = []*Role{
+	{Name: "translator", Description: "Translate binded languages", Permission: CanRead | CanEdit},
+	{Name: "reviewer", Description: "Read, edit and review all translations", Permission: CanReadAll | CanEditAll | CanReview},
+	{Name: "developer", Description: "Manage messages", Permission: CanReadAll | CanAppend | CanDelete},
+	{Name: "manager", Description: "Total access", Permission: CanEverything},
+}
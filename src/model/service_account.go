@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ServiceAccount is a non-login principal of machine integrations
+//
+// It is owned by admin and authenticated only by it's credentials,
+// which are access tokens with Service set and UserID of service account.
+// Credentials have Permission and Languages of service account.
+//
+// nolint: aligncheck
+type ServiceAccount struct {
+	ID          bson.ObjectId `bson:"_id" json:"id"`
+	Name        string        `bson:"name" json:"name"`
+	Description string        `bson:"description,omitempty" json:"description,omitempty"`
+	OwnerID     bson.ObjectId `bson:"ownerId" json:"ownerId"`
+	Permission  Permission    `bson:"permission" json:"permission"`
+	Languages   []string      `bson:"languages,omitempty" json:"languages,omitempty"`
+	CreatedAt   time.Time     `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time     `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Session is an interactive login of user on some client
+//
+// ID of session is a family of it's refresh tokens and sid of it's jwt,
+// so removing session revoke both of them.
+// Current is set only in response to mark session of request.
+//
+// nolint: aligncheck
+type Session struct {
+	ID         bson.ObjectId `bson:"_id" json:"id"`
+	UserID     bson.ObjectId `bson:"userId" json:"userId"`
+	UserAgent  string        `bson:"userAgent" json:"userAgent"`
+	IP         string        `bson:"ip" json:"ip"`
+	CreatedAt  time.Time     `bson:"createdAt" json:"createdAt"`
+	LastSeenAt time.Time     `bson:"lastSeenAt" json:"lastSeenAt"`
+	ExpiresAt  time.Time     `bson:"expiresAt" json:"expiresAt"`
+	Current    bool          `bson:"-" json:"current,omitempty"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// RefreshToken is a single use token to get new access token
+//
+// Every refresh return new token of the same family,
+// so reuse of token can revoke whole family
+//
+// nolint: aligncheck
+type RefreshToken struct {
+	ID        bson.ObjectId `bson:"_id"`
+	Hash      []byte        `bson:"hash"`
+	Family    bson.ObjectId `bson:"family"`
+	UserID    bson.ObjectId `bson:"userId"`
+	CreatedAt time.Time     `bson:"createdAt"`
+	ExpiresAt time.Time     `bson:"expiresAt"`
+	UsedAt    *time.Time    `bson:"usedAt"`
+}
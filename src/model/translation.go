@@ -0,0 +1,108 @@
+package model
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// TranslationState is a state of translation in review workflow
+type TranslationState string
+
+const (
+	// Untranslated is a state of message without translation
+	Untranslated TranslationState = "untranslated"
+	// Draft is a state of saved translation
+	Draft TranslationState = "draft"
+	// NeedsReview is a state of translation submitted to review
+	NeedsReview TranslationState = "needs_review"
+	// Approved is a state of translation ready to export
+	Approved TranslationState = "approved"
+	// Rejected is a state of translation rejected by reviewer with reason
+	Rejected TranslationState = "rejected"
+)
+
+// transitions are allowed changes of state except Draft, which
+// is set on every save of translation
+var transitions = map[TranslationState][]TranslationState{
+	Draft:       {NeedsReview},
+	NeedsReview: {Approved, Rejected},
+	Approved:    {Rejected},
+	Rejected:    {NeedsReview},
+}
+
+// CanTransit return true if translation in state from can be changed to state to
+func CanTransit(from, to TranslationState) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MaxTextLength is a maximum length of translation text in characters
+const MaxTextLength = 10000
+
+// Translation is a text of message in one of target languages of project
+//
+// It is unique by MessageID and Language. AuthorID is a user or
+// service account, which saved translation last time. Revision is
+// a number of last TranslationRevision. Reason is set for rejected one.
+// ApprovedRevision and ApprovedText keep last approved revision to export
+// it while newer one is reviewed, they are cleared when approved
+// revision is rejected.
+//
+// nolint: aligncheck
+type Translation struct {
+	ID               bson.ObjectId    `bson:"_id" json:"id"`
+	ProjectID        bson.ObjectId    `bson:"projectId" json:"projectId"`
+	MessageID        bson.ObjectId    `bson:"messageId" json:"messageId"`
+	Language         string           `bson:"language" json:"language"`
+	Text             string           `bson:"text" json:"text"`
+	Revision         int              `bson:"revision" json:"revision"`
+	State            TranslationState `bson:"state" json:"state"`
+	Reason           string           `bson:"reason,omitempty" json:"reason,omitempty"`
+	ApprovedRevision int              `bson:"approvedRevision,omitempty" json:"approvedRevision,omitempty"`
+	ApprovedText     string           `bson:"approvedText,omitempty" json:"approvedText,omitempty"`
+	AuthorID         bson.ObjectId    `bson:"authorId" json:"authorId"`
+	AuthorService    bool             `bson:"authorService,omitempty" json:"authorService,omitempty"`
+	CreatedAt        time.Time        `bson:"createdAt" json:"createdAt"`
+	UpdatedAt        time.Time        `bson:"updatedAt" json:"updatedAt"`
+}
+
+// TranslationRevision is a saved version of translation
+//
+// Revisions of translation are numbered from 1. RevertedFrom is
+// a number of revision, which text is restored by this one.
+//
+// nolint: aligncheck
+type TranslationRevision struct {
+	ID            bson.ObjectId `bson:"_id" json:"id"`
+	MessageID     bson.ObjectId `bson:"messageId" json:"messageId"`
+	Language      string        `bson:"language" json:"language"`
+	Number        int           `bson:"number" json:"number"`
+	Text          string        `bson:"text" json:"text"`
+	AuthorID      bson.ObjectId `bson:"authorId" json:"authorId"`
+	AuthorService bool          `bson:"authorService,omitempty" json:"authorService,omitempty"`
+	RevertedFrom  int           `bson:"revertedFrom,omitempty" json:"revertedFrom,omitempty"`
+	CreatedAt     time.Time     `bson:"createdAt" json:"createdAt"`
+}
+
+// TranslationTransition is a record of change of translation state
+//
+// ActorID is a user or service account, which changed state.
+//
+// nolint: aligncheck
+type TranslationTransition struct {
+	ID           bson.ObjectId    `bson:"_id" json:"id"`
+	MessageID    bson.ObjectId    `bson:"messageId" json:"messageId"`
+	Language     string           `bson:"language" json:"language"`
+	From         TranslationState `bson:"from" json:"from"`
+	To           TranslationState `bson:"to" json:"to"`
+	Reason       string           `bson:"reason,omitempty" json:"reason,omitempty"`
+	ActorID      bson.ObjectId    `bson:"actorId" json:"actorId"`
+	ActorService bool             `bson:"actorService,omitempty" json:"actorService,omitempty"`
+	CreatedAt    time.Time        `bson:"createdAt" json:"createdAt"`
+}
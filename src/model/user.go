@@ -8,15 +8,63 @@ import (
 
 // User of l10n-center
 //
+// TokenVersion is incremented to revoke all previously issued access tokens,
+// refresh tokens are removed with sessions to revoke them.
+// TOTPSecret is pending until TOTPEnabledAt is set, TOTPStep is a last
+// used time step to reject replayed codes, RecoveryCodes are hashed.
+// Languages are BCP 47 tags binded to user for CanRead and CanEdit.
+// Roles are names of roles, which permissions are added to Permission.
+// Groups are IDs of groups, which permissions and languages are inherited.
+// PendingEmail replace Email when user confirm it by hashed EmailToken,
+// which also set EmailVerifiedAt.
+//
 // nolint: aligncheck
 type User struct {
-	ID         bson.ObjectId `bson:"_id"`
-	Email      string        `bson:"email"`
-	Passhash   []byte        `bson:"passhash"`
-	ResetToken []byte        `bson:"resetToken"`
-	IsAdmin    bool          `bson:"isAdmin"`
-	Permission Permission    `bson:"permission"`
-	CreatedAt  time.Time     `bson:"createdAt"`
-	UpdatedAt  time.Time     `bson:"updatedAt"`
-	DeletedAt  *time.Time    `bson:"deletedAt"`
+	ID              bson.ObjectId   `bson:"_id" json:"id"`
+	Email           string          `bson:"email" json:"email"`
+	PendingEmail    string          `bson:"pendingEmail,omitempty" json:"pendingEmail,omitempty"`
+	EmailToken      []byte          `bson:"emailToken,omitempty" json:"-"`
+	EmailUntil      *time.Time      `bson:"emailUntil,omitempty" json:"-"`
+	EmailVerifiedAt *time.Time      `bson:"emailVerifiedAt,omitempty" json:"emailVerifiedAt,omitempty"`
+	Passhash        []byte          `bson:"passhash" json:"-"`
+	ResetToken      []byte          `bson:"resetToken" json:"-"`
+	ResetUntil      *time.Time      `bson:"resetUntil" json:"-"`
+	InviteNonce     string          `bson:"inviteNonce,omitempty" json:"-"`
+	InvitedAt       *time.Time      `bson:"invitedAt,omitempty" json:"invitedAt,omitempty"`
+	IsAdmin         bool            `bson:"isAdmin" json:"isAdmin"`
+	Permission      Permission      `bson:"permission" json:"permission"`
+	Languages       []string        `bson:"languages,omitempty" json:"languages,omitempty"`
+	Roles           []string        `bson:"roles,omitempty" json:"roles,omitempty"`
+	Groups          []bson.ObjectId `bson:"groups,omitempty" json:"groups,omitempty"`
+	CreatedAt       time.Time       `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time       `bson:"updatedAt" json:"updatedAt"`
+	DeletedAt       *time.Time      `bson:"deletedAt" json:"deletedAt,omitempty"`
+	TokenVersion    int             `bson:"tokenVersion" json:"-"`
+	TOTPSecret      string          `bson:"totpSecret,omitempty" json:"-"`
+	TOTPEnabledAt   *time.Time      `bson:"totpEnabledAt,omitempty" json:"totpEnabledAt,omitempty"`
+	TOTPStep        int64           `bson:"totpStep,omitempty" json:"-"`
+	RecoveryCodes   [][]byte        `bson:"recoveryCodes,omitempty" json:"-"`
+	Preferences     Preferences     `bson:"preferences" json:"preferences"`
+}
+
+// UserChange is a change of fields, which are contained in claims of user,
+// so it revokes all issued tokens of user
+//
+// Nil fields are not changed. Changed Email is not verified.
+type UserChange struct {
+	Email      *string
+	Passhash   []byte
+	IsAdmin    *bool
+	Permission *Permission
+	Roles      *[]string
+}
+
+// Preferences of user interface
+//
+// Locale and languages are BCP 47 tags, Timezone is an IANA time zone name
+type Preferences struct {
+	Locale          string   `bson:"locale,omitempty" json:"locale,omitempty"`
+	Timezone        string   `bson:"timezone,omitempty" json:"timezone,omitempty"`
+	SourceLanguage  string   `bson:"sourceLanguage,omitempty" json:"sourceLanguage,omitempty"`
+	TargetLanguages []string `bson:"targetLanguages,omitempty" json:"targetLanguages,omitempty"`
 }
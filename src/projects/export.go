@@ -0,0 +1,92 @@
+package projects
+
+import (
+	"net/http"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/pressly/chi/render"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ExportEntry is a translated message in export
+type ExportEntry struct {
+	Key     string                 `json:"key"`
+	Context string                 `json:"context,omitempty"`
+	Text    string                 `json:"text"`
+	State   model.TranslationState `json:"state"`
+}
+
+// ExportPageSize is a number of messages loaded at once for export
+const ExportPageSize = 500
+
+// Export translations of project on language in order of messages creation
+//
+// Only last approved revisions of translations are exported if project
+// is ApprovedOnly or query param approved is true, so edited translation
+// is exported as approved until new revision is approved. Messages are
+// loaded by pages of ExportPageSize with their translations
+func Export(_ *config.Config, store Store) http.HandlerFunc {
+	// Export translations of project on language
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		lang, ok := urlLanguage(w, r, false)
+		if !ok {
+			return
+		}
+		p, ok := urlProject(w, r, store, false)
+		if !ok {
+			return
+		}
+		approvedOnly := p.ApprovedOnly || r.URL.Query().Get("approved") == "true"
+		entries := []*ExportEntry{}
+		q := &model.MessageQuery{ProjectID: p.ID, Limit: ExportPageSize}
+		for {
+			ml, err := store.GetMessages(ctx, q)
+			if err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+			if len(ml) == 0 {
+				break
+			}
+			ids := make([]bson.ObjectId, len(ml))
+			for i, msg := range ml {
+				ids[i] = msg.ID
+			}
+			tl, err := store.GetTranslations(ctx, ids, lang, approvedOnly)
+			if err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+			translations := make(map[bson.ObjectId]*model.Translation, len(tl))
+			for _, t := range tl {
+				translations[t.MessageID] = t
+			}
+			for _, msg := range ml {
+				t, ok := translations[msg.ID]
+				if !ok {
+					continue
+				}
+				e := &ExportEntry{Key: msg.Key, Context: msg.Context, Text: t.Text, State: state(t)}
+				if approvedOnly {
+					e.Text, e.State = t.ApprovedText, model.Approved
+				}
+				entries = append(entries, e)
+			}
+			if len(ml) < q.Limit {
+				break
+			}
+			q.Cursor = ml[len(ml)-1].ID
+		}
+		render.JSON(w, r, entries)
+	}
+	return http.HandlerFunc(fn)
+}
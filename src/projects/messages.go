@@ -0,0 +1,435 @@
+package projects
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// DefaultLimit of messages per page
+	DefaultLimit = 100
+	// MaxLimit of messages per page
+	MaxLimit = 1000
+	// MaxBatch is a maximum count of messages created at once
+	MaxBatch = 1000
+)
+
+// MessagePage is a page of messages with cursor of next page
+type MessagePage struct {
+	Messages   []*model.Message `json:"messages"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+// messageRequest is a body of message creation
+type messageRequest struct {
+	Key         string   `json:"key" valid:"required"`
+	Context     string   `json:"context"`
+	Text        string   `json:"text" valid:"required"`
+	Description string   `json:"description"`
+	MaxLength   int      `json:"maxLength"`
+	Tags        []string `json:"tags"`
+}
+
+// message validate request and create message of project from it
+func (rd *messageRequest) message(projectID bson.ObjectId) (*model.Message, error) {
+	if ok, err := govalidator.ValidateStruct(rd); !ok {
+		return nil, errors.WithMessage(err, "validate")
+	}
+	if rd.MaxLength < 0 {
+		return nil, errors.New("validate: maxLength: must not be negative")
+	}
+
+	return &model.Message{
+		ID:          bson.NewObjectId(),
+		ProjectID:   projectID,
+		Key:         rd.Key,
+		Context:     rd.Context,
+		Text:        rd.Text,
+		Description: rd.Description,
+		MaxLength:   rd.MaxLength,
+		Tags:        normalizeTags(rd.Tags),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}, nil
+}
+
+// normalizeTags trim tags and remove empty and duplicated ones
+func normalizeTags(tags []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
+
+// urlProject load project from url param and check it is not archived if active
+//
+// Response is written if project is not found or archived
+func urlProject(w http.ResponseWriter, r *http.Request, store Store, active bool) (*model.Project, bool) {
+	ctx := r.Context()
+	l := tracing.Logger(ctx)
+
+	id, ok := objectID(r, "id")
+	if !ok {
+		l.Debug("bad project id")
+		http.Error(w, "project not found", http.StatusNotFound)
+		return nil, false
+	}
+	p, err := store.GetProjectByID(ctx, id)
+	if errors.Cause(err) == errs.ModelNotFound {
+		l.Debug(err.Error(), zap.Error(err))
+		http.Error(w, "project not found", http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		l.Error(err.Error(), errs.ZapStack(err))
+		http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+		return nil, false
+	}
+	if active && p.ArchivedAt != nil {
+		l.Debug("project is archived")
+		http.Error(w, "project is archived", http.StatusConflict)
+		return nil, false
+	}
+
+	return p, true
+}
+
+// projectMessage load message of project by key from url param
+// and context from url query
+//
+// Response is written if message is not found
+func projectMessage(w http.ResponseWriter, r *http.Request, store Store, p *model.Project) (*model.Message, bool) {
+	ctx := r.Context()
+	l := tracing.Logger(ctx)
+
+	msg, err := store.GetMessageByKey(ctx, p.ID, chi.URLParam(r, "key"), r.URL.Query().Get("context"))
+	if errors.Cause(err) == errs.ModelNotFound {
+		l.Debug(err.Error(), zap.Error(err))
+		http.Error(w, "message not found", http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		l.Error(err.Error(), errs.ZapStack(err))
+		http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return msg, true
+}
+
+// parseMessageQuery of project messages from url query
+//
+// key is a prefix of key, q is a substring of key or text
+// and cursor is an id of last message of previous page
+func parseMessageQuery(r *http.Request) (*model.MessageQuery, string) {
+	v := r.URL.Query()
+	q := &model.MessageQuery{
+		KeyPrefix: v.Get("key"),
+		Tag:       v.Get("tag"),
+		Search:    v.Get("q"),
+		Limit:     DefaultLimit,
+	}
+	if _, ok := v["context"]; ok {
+		context := v.Get("context")
+		q.Context = &context
+	}
+	if s := v.Get("cursor"); s != "" {
+		if !bson.IsObjectIdHex(s) {
+			return nil, "cursor: must be an id"
+		}
+		q.Cursor = bson.ObjectIdHex(s)
+	}
+	if s := v.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return nil, "limit: must be from 1 to " + strconv.Itoa(MaxLimit)
+		}
+		q.Limit = limit
+	}
+
+	return q, ""
+}
+
+// Messages of project filtered by key prefix, context, tag and search string
+func Messages(_ *config.Config, store Store) http.HandlerFunc {
+	// Messages of project
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		q, msg := parseMessageQuery(r)
+		if q == nil {
+			l.Debug(msg)
+			http.Error(w, "validate: "+msg, http.StatusBadRequest)
+			return
+		}
+		p, ok := urlProject(w, r, store, false)
+		if !ok {
+			return
+		}
+		q.ProjectID = p.ID
+		ml, err := store.GetMessages(ctx, q)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		page := &MessagePage{Messages: ml}
+		if len(ml) == q.Limit {
+			page.NextCursor = ml[len(ml)-1].ID.Hex()
+		}
+		render.JSON(w, r, page)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// CreateMessage in active project
+func CreateMessage(_ *config.Config, store Store) http.HandlerFunc {
+	// Create message in project
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &messageRequest{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		p, ok := urlProject(w, r, store, true)
+		if !ok {
+			return
+		}
+		msg, err := rd.message(p.ID)
+		if err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = store.CreateMessage(ctx, msg)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "message already exists", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "message.create", msg.ID.Hex(), nil, msg)
+		l.Sugar().Infof("message <%s> of project <%s> created", msg.Key, p.Slug)
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, msg)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// BatchResult is a result of batch creation of messages
+//
+// Skipped messages have same key and context as existing ones
+type BatchResult struct {
+	Created []*model.Message `json:"created"`
+	Skipped []*model.Message `json:"skipped"`
+}
+
+// CreateMessages in active project at once
+//
+// Messages which already exist are skipped, all other are created
+func CreateMessages(_ *config.Config, store Store) http.HandlerFunc {
+	// Create batch of messages in project
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rdl := []*messageRequest{}
+		if err := errors.WithMessage(jd.Decode(&rdl), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(rdl) == 0 || len(rdl) > MaxBatch {
+			l.Debug("bad batch size")
+			http.Error(w, "validate: batch must contain from 1 to "+strconv.Itoa(MaxBatch)+" messages", http.StatusBadRequest)
+			return
+		}
+		p, ok := urlProject(w, r, store, true)
+		if !ok {
+			return
+		}
+		ml := make([]*model.Message, 0, len(rdl))
+		for i, rd := range rdl {
+			msg, err := rd.message(p.ID)
+			if err != nil {
+				l.Debug(err.Error())
+				http.Error(w, strconv.Itoa(i)+": "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			ml = append(ml, msg)
+		}
+		skipped, err := store.CreateMessages(ctx, ml)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		res := &BatchResult{Created: []*model.Message{}, Skipped: []*model.Message{}}
+		isSkipped := map[int]bool{}
+		for _, i := range skipped {
+			isSkipped[i] = true
+		}
+		for i, msg := range ml {
+			if isSkipped[i] {
+				res.Skipped = append(res.Skipped, msg)
+			} else {
+				res.Created = append(res.Created, msg)
+			}
+		}
+		auth.Audit(ctx, store, r, "message.batch", p.ID.Hex(), nil, map[string]int{"created": len(res.Created)})
+		l.Sugar().Infof("%d messages of project <%s> created", len(res.Created), p.Slug)
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, res)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// UpdateMessage fields present in request
+//
+// Message is addressed by key in url and context in url query
+func UpdateMessage(_ *config.Config, store Store) http.HandlerFunc {
+	// Update message fields present in request
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Key         *string   `json:"key"`
+			Context     *string   `json:"context"`
+			Text        *string   `json:"text"`
+			Description *string   `json:"description"`
+			MaxLength   *int      `json:"maxLength"`
+			Tags        *[]string `json:"tags"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if rd.Key != nil && *rd.Key == "" || rd.Text != nil && *rd.Text == "" {
+			l.Debug("empty key or text")
+			http.Error(w, "validate: key and text: non zero value required", http.StatusBadRequest)
+			return
+		}
+		if rd.MaxLength != nil && *rd.MaxLength < 0 {
+			l.Debug("negative max length")
+			http.Error(w, "validate: maxLength: must not be negative", http.StatusBadRequest)
+			return
+		}
+		p, ok := urlProject(w, r, store, true)
+		if !ok {
+			return
+		}
+		msg, ok := projectMessage(w, r, store, p)
+		if !ok {
+			return
+		}
+		before := *msg
+		if rd.Key != nil {
+			msg.Key = *rd.Key
+		}
+		if rd.Context != nil {
+			msg.Context = *rd.Context
+		}
+		if rd.Text != nil {
+			msg.Text = *rd.Text
+		}
+		if rd.Description != nil {
+			msg.Description = *rd.Description
+		}
+		if rd.MaxLength != nil {
+			msg.MaxLength = *rd.MaxLength
+		}
+		if rd.Tags != nil {
+			msg.Tags = normalizeTags(*rd.Tags)
+		}
+		msg.UpdatedAt = time.Now()
+		err := store.UpdateMessage(ctx, msg)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "message already exists", http.StatusConflict)
+			return
+		} else if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "message not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "message.update", msg.ID.Hex(), &before, msg)
+		l.Sugar().Infof("message <%s> of project <%s> updated", msg.Key, p.Slug)
+		render.JSON(w, r, msg)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// DeleteMessage from active project with all it's translations
+//
+// Message is addressed by key in url and context in url query
+func DeleteMessage(_ *config.Config, store Store) http.HandlerFunc {
+	// Delete message from project
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		p, ok := urlProject(w, r, store, true)
+		if !ok {
+			return
+		}
+		msg, ok := projectMessage(w, r, store, p)
+		if !ok {
+			return
+		}
+		err := store.DeleteMessage(ctx, p.ID, msg.ID)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "message not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "message.delete", msg.ID.Hex(), nil, nil)
+		l.Sugar().Infof("message <%s> of project <%s> deleted", msg.Key, p.Slug)
+		http.Error(w, "message deleted", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,306 @@
+package projects_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/projects"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pressly/chi"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestMessages(t *testing.T) {
+	editor := &model.User{ID: bson.NewObjectId(), Email: "editor@email.com", Permission: model.CanRead | model.CanAppend}
+	reader := &model.User{ID: bson.NewObjectId(), Email: "reader@email.com", Permission: model.CanRead}
+	cleaner := &model.User{ID: bson.NewObjectId(), Email: "cleaner@email.com", Permission: model.CanRead | model.CanDelete}
+	site := &model.Project{ID: bson.NewObjectId(), Slug: "site", SourceLanguage: "en"}
+	archived := time.Now()
+	old := &model.Project{ID: bson.NewObjectId(), Slug: "old", SourceLanguage: "en", ArchivedAt: &archived}
+	hello := &model.Message{ID: bson.NewObjectId(), ProjectID: site.ID, Key: "hello", Text: "Hello"}
+	path := "/projects/" + site.ID.Hex() + "/messages"
+
+	cases := []struct {
+		name   string
+		store  func(*gomock.Controller) *MockStore
+		user   *model.User
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{
+			name: "List bad cursor",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   reader,
+			method: "GET",
+			path:   path + "?cursor=bad",
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "List filtered",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessages(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, q *model.MessageQuery) {
+						require.Equal(t, site.ID, q.ProjectID)
+						require.Equal(t, "menu.", q.KeyPrefix)
+						require.Equal(t, "ui", q.Tag)
+						require.NotNil(t, q.Context)
+						require.Equal(t, "", *q.Context)
+						require.Equal(t, 10, q.Limit)
+					}).
+					Return([]*model.Message{hello}, nil)
+
+				return store
+			},
+			user:   reader,
+			method: "GET",
+			path:   path + "?key=menu.&tag=ui&context=&limit=10",
+			code:   http.StatusOK,
+		},
+		{
+			name: "List unknown project",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), old.ID).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			user:   reader,
+			method: "GET",
+			path:   "/projects/" + old.ID.Hex() + "/messages",
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Create without append",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   reader,
+			method: "POST",
+			path:   path,
+			body:   `{"key":"hello","text":"Hello"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Create in archived project",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), old.ID).
+					Return(old, nil)
+
+				return store
+			},
+			user:   editor,
+			method: "POST",
+			path:   "/projects/" + old.ID.Hex() + "/messages",
+			body:   `{"key":"hello","text":"Hello"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Create without text",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+
+				return store
+			},
+			user:   editor,
+			method: "POST",
+			path:   path,
+			body:   `{"key":"hello"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Create",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					CreateMessage(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, msg *model.Message) {
+						require.Equal(t, site.ID, msg.ProjectID)
+						require.Equal(t, "button", msg.Context)
+						require.Equal(t, 10, msg.MaxLength)
+						require.Equal(t, []string{"ui"}, msg.Tags)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   editor,
+			method: "POST",
+			path:   path,
+			body:   `{"key":"hello","context":"button","text":"Hello","maxLength":10,"tags":["ui"," ui",""]}`,
+			code:   http.StatusCreated,
+		},
+		{
+			name: "Create duplicate",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					CreateMessage(gomock.Any(), gomock.Any()).
+					Return(errs.ModelExists)
+
+				return store
+			},
+			user:   editor,
+			method: "POST",
+			path:   path,
+			body:   `{"key":"hello","text":"Hello"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Batch empty",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   editor,
+			method: "POST",
+			path:   path + "/batch",
+			body:   `[]`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Batch",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					CreateMessages(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, ml []*model.Message) {
+						require.Len(t, ml, 2)
+					}).
+					Return([]int{0}, nil)
+
+				return store
+			},
+			user:   editor,
+			method: "POST",
+			path:   path + "/batch",
+			body:   `[{"key":"hello","text":"Hello"},{"key":"bye","text":"Bye"}]`,
+			code:   http.StatusCreated,
+		},
+		{
+			name: "Update duplicate",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(&model.Message{ID: hello.ID, ProjectID: site.ID, Key: "hello", Text: "Hello"}, nil)
+				store.EXPECT().
+					UpdateMessage(gomock.Any(), gomock.Any()).
+					Return(errs.ModelExists)
+
+				return store
+			},
+			user:   editor,
+			method: "PATCH",
+			path:   path + "/hello",
+			body:   `{"key":"bye"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Delete without delete",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   editor,
+			method: "DELETE",
+			path:   path + "/hello",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Delete",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "button").
+					Return(hello, nil)
+				store.EXPECT().
+					DeleteMessage(gomock.Any(), site.ID, hello.ID).
+					Return(nil)
+
+				return store
+			},
+			user:   cleaner,
+			method: "DELETE",
+			path:   path + "/hello?context=button",
+			code:   http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(withNoopSpan)
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			r.Route("/projects", projects.Router(cfg, keys, store))
+
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			if c.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			st, err := auth.CreateToken(context.Background(), keys, store, c.user)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+st)
+			store.EXPECT().
+				IsTokenRevoked(gomock.Any(), gomock.Any()).
+				Return(false, nil)
+			store.EXPECT().
+				GetUserByID(gomock.Any(), c.user.ID).
+				Return(c.user, nil)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
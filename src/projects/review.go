@@ -0,0 +1,166 @@
+package projects
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/pkg/errors"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// state of translation in review workflow
+//
+// Translations saved before review workflow have no state and are drafts
+func state(t *model.Translation) model.TranslationState {
+	if t.State == "" {
+		return model.Draft
+	}
+
+	return t.State
+}
+
+// ChangeState of translation in review workflow
+//
+// Translation is submitted to review with permission to edit language
+// and approved or rejected with CanReview and permission to read it.
+// Rejection require reason and author can't approve own translation
+func ChangeState(_ *config.Config, store Store) http.HandlerFunc {
+	// Change state of translation
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			State  model.TranslationState `json:"state"`
+			Reason string                 `json:"reason"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c, _ := auth.ClaimsFromContext(ctx)
+		switch rd.State {
+		case model.NeedsReview:
+		case model.Approved, model.Rejected:
+			if !c.IsAdmin && c.Permission&model.CanReview == 0 {
+				l.Debug("no review permission")
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+		default:
+			l.Debug("bad state", zap.String("state", string(rd.State)))
+			http.Error(w, "validate: state: must be needs_review, approved or rejected", http.StatusBadRequest)
+			return
+		}
+		if rd.State == model.Rejected && rd.Reason == "" {
+			l.Debug("rejection without reason")
+			http.Error(w, "validate: reason: non zero value required", http.StatusBadRequest)
+			return
+		}
+		lang, ok := urlLanguage(w, r, rd.State == model.NeedsReview)
+		if !ok {
+			return
+		}
+		p, ok := urlProject(w, r, store, true)
+		if !ok {
+			return
+		}
+		msg, ok := projectMessage(w, r, store, p)
+		if !ok {
+			return
+		}
+		t, err := store.GetTranslation(ctx, msg.ID, lang)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "translation not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		from := state(t)
+		if !model.CanTransit(from, rd.State) {
+			l.Debug("bad transition", zap.String("from", string(from)), zap.String("to", string(rd.State)))
+			http.Error(w, "translation can't be changed from "+string(from)+" to "+string(rd.State), http.StatusConflict)
+			return
+		}
+		if rd.State == model.Approved && t.AuthorID == c.UserID {
+			l.Debug("approval by author")
+			http.Error(w, "translation can't be approved by it's author", http.StatusForbidden)
+			return
+		}
+		tr := &model.TranslationTransition{
+			ID:           bson.NewObjectId(),
+			MessageID:    msg.ID,
+			Language:     lang,
+			From:         from,
+			To:           rd.State,
+			ActorID:      c.UserID,
+			ActorService: c.Service,
+			CreatedAt:    time.Now(),
+		}
+		if rd.State == model.Rejected {
+			tr.Reason = rd.Reason
+		}
+		err = store.ChangeTranslationState(ctx, t, tr)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "translation changed concurrently", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		before := *t
+		t.State = tr.To
+		t.Reason = tr.Reason
+		auth.Audit(ctx, store, r, "translation.state", t.ID.Hex(), &before, t)
+		l.Sugar().Infof("translation <%s> of message <%s> changed to %s", lang, msg.Key, t.State)
+		render.JSON(w, r, t)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Transitions of translation state from newest to oldest
+func Transitions(_ *config.Config, store Store) http.HandlerFunc {
+	// Transitions of translation state
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		lang, ok := urlLanguage(w, r, false)
+		if !ok {
+			return
+		}
+		p, ok := urlProject(w, r, store, false)
+		if !ok {
+			return
+		}
+		msg, ok := projectMessage(w, r, store, p)
+		if !ok {
+			return
+		}
+		tl, err := store.GetTranslationTransitions(ctx, msg.ID, lang)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, tl)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,387 @@
+package projects_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/projects"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pressly/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestReview(t *testing.T) {
+	translator := &model.User{ID: bson.NewObjectId(), Email: "translator@email.com", Permission: model.CanRead | model.CanEdit, Languages: []string{"de"}}
+	reviewer := &model.User{ID: bson.NewObjectId(), Email: "reviewer@email.com", Permission: model.CanReadAll | model.CanReview}
+	reader := &model.User{ID: bson.NewObjectId(), Email: "reader@email.com", Permission: model.CanReadAll}
+	site := &model.Project{ID: bson.NewObjectId(), Slug: "site", SourceLanguage: "en", TargetLanguages: []string{"de", "fr"}}
+	strict := &model.Project{ID: bson.NewObjectId(), Slug: "strict", SourceLanguage: "en", TargetLanguages: []string{"de"}, ApprovedOnly: true}
+	hello := &model.Message{ID: bson.NewObjectId(), ProjectID: site.ID, Key: "hello", Text: "Hello"}
+	translation := func(state model.TranslationState, author bson.ObjectId) *model.Translation {
+		return &model.Translation{
+			ID:        bson.NewObjectId(),
+			ProjectID: site.ID,
+			MessageID: hello.ID,
+			Language:  "de",
+			Text:      "Hallo",
+			Revision:  1,
+			State:     state,
+			AuthorID:  author,
+		}
+	}
+	path := "/projects/" + site.ID.Hex() + "/messages/hello/translations/de/"
+	found := func(store *MockStore, t *model.Translation) {
+		store.EXPECT().
+			GetProjectByID(gomock.Any(), site.ID).
+			Return(site, nil)
+		store.EXPECT().
+			GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+			Return(hello, nil)
+		store.EXPECT().
+			GetTranslation(gomock.Any(), hello.ID, "de").
+			Return(t, nil)
+	}
+
+	cases := []struct {
+		name     string
+		store    func(*gomock.Controller) *MockStore
+		user     *model.User
+		method   string
+		path     string
+		body     string
+		code     int
+		contains string
+	}{
+		{
+			name: "Set draft",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   translator,
+			method: "POST",
+			path:   path + "state",
+			body:   `{"state":"draft"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Approve without review",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   reader,
+			method: "POST",
+			path:   path + "state",
+			body:   `{"state":"approved"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Reject without reason",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   reviewer,
+			method: "POST",
+			path:   path + "state",
+			body:   `{"state":"rejected"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Submit",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				found(store, translation("", translator.ID))
+				store.EXPECT().
+					ChangeTranslationState(gomock.Any(), gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, _ *model.Translation, tr *model.TranslationTransition) {
+						require.Equal(t, model.Draft, tr.From)
+						require.Equal(t, model.NeedsReview, tr.To)
+						require.Equal(t, translator.ID, tr.ActorID)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:     translator,
+			method:   "POST",
+			path:     path + "state",
+			body:     `{"state":"needs_review"}`,
+			code:     http.StatusOK,
+			contains: `"state":"needs_review"`,
+		},
+		{
+			name: "Submit approved",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				found(store, translation(model.Approved, translator.ID))
+
+				return store
+			},
+			user:   translator,
+			method: "POST",
+			path:   path + "state",
+			body:   `{"state":"needs_review"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Approve by author",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				found(store, translation(model.NeedsReview, reviewer.ID))
+
+				return store
+			},
+			user:   reviewer,
+			method: "POST",
+			path:   path + "state",
+			body:   `{"state":"approved"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Approve concurrently",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				found(store, translation(model.NeedsReview, translator.ID))
+				store.EXPECT().
+					ChangeTranslationState(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(errs.ModelNotFound)
+
+				return store
+			},
+			user:   reviewer,
+			method: "POST",
+			path:   path + "state",
+			body:   `{"state":"approved"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Approve",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				found(store, translation(model.NeedsReview, translator.ID))
+				store.EXPECT().
+					ChangeTranslationState(gomock.Any(), gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, _ *model.Translation, tr *model.TranslationTransition) {
+						require.Equal(t, model.Approved, tr.To)
+						require.Equal(t, reviewer.ID, tr.ActorID)
+						require.False(t, tr.CreatedAt.IsZero())
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   reviewer,
+			method: "POST",
+			path:   path + "state",
+			body:   `{"state":"approved"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Reject approved",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				found(store, translation(model.Approved, translator.ID))
+				store.EXPECT().
+					ChangeTranslationState(gomock.Any(), gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, _ *model.Translation, tr *model.TranslationTransition) {
+						require.Equal(t, model.Rejected, tr.To)
+						require.Equal(t, "typo", tr.Reason)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:     reviewer,
+			method:   "POST",
+			path:     path + "state",
+			body:     `{"state":"rejected","reason":"typo"}`,
+			code:     http.StatusOK,
+			contains: `"reason":"typo"`,
+		},
+		{
+			name: "Transitions",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(hello, nil)
+				store.EXPECT().
+					GetTranslationTransitions(gomock.Any(), hello.ID, "de").
+					Return([]*model.TranslationTransition{{From: model.Draft, To: model.NeedsReview, ActorID: translator.ID}}, nil)
+
+				return store
+			},
+			user:   translator,
+			method: "GET",
+			path:   path + "transitions",
+			code:   http.StatusOK,
+		},
+		{
+			name: "Export not binded language",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   translator,
+			method: "GET",
+			path:   "/projects/" + site.ID.Hex() + "/export/fr",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Export",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				bye := &model.Message{ID: bson.NewObjectId(), Key: "bye"}
+				store.EXPECT().
+					GetMessages(gomock.Any(), &model.MessageQuery{ProjectID: site.ID, Limit: projects.ExportPageSize}).
+					Return([]*model.Message{hello, bye}, nil)
+				store.EXPECT().
+					GetTranslations(gomock.Any(), []bson.ObjectId{hello.ID, bye.ID}, "de", false).
+					Return([]*model.Translation{translation(model.Draft, translator.ID)}, nil)
+
+				return store
+			},
+			user:     translator,
+			method:   "GET",
+			path:     "/projects/" + site.ID.Hex() + "/export/de",
+			code:     http.StatusOK,
+			contains: `[{"key":"hello","text":"Hallo","state":"draft"}]`,
+		},
+		{
+			name: "Export approved by query",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessages(gomock.Any(), gomock.Any()).
+					Return([]*model.Message{hello}, nil)
+				// Approved translation is edited after approval
+				edited := translation(model.Draft, translator.ID)
+				edited.Text = "Hallo Welt"
+				edited.Revision = 2
+				edited.ApprovedRevision = 1
+				edited.ApprovedText = "Hallo"
+				store.EXPECT().
+					GetTranslations(gomock.Any(), []bson.ObjectId{hello.ID}, "de", true).
+					Return([]*model.Translation{edited}, nil)
+
+				return store
+			},
+			user:     translator,
+			method:   "GET",
+			path:     "/projects/" + site.ID.Hex() + "/export/de?approved=true",
+			code:     http.StatusOK,
+			contains: `[{"key":"hello","text":"Hallo","state":"approved"}]`,
+		},
+		{
+			name: "Export approved only project",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), strict.ID).
+					Return(strict, nil)
+				store.EXPECT().
+					GetMessages(gomock.Any(), gomock.Any()).
+					Return([]*model.Message{}, nil)
+
+				return store
+			},
+			user:     translator,
+			method:   "GET",
+			path:     "/projects/" + strict.ID.Hex() + "/export/de",
+			code:     http.StatusOK,
+			contains: `[]`,
+		},
+		{
+			name: "Export by pages",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				page := make([]*model.Message, projects.ExportPageSize)
+				for i := range page {
+					page[i] = &model.Message{ID: bson.NewObjectId(), Key: "key"}
+				}
+				page[0] = hello
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessages(gomock.Any(), &model.MessageQuery{ProjectID: site.ID, Limit: projects.ExportPageSize}).
+					Return(page, nil)
+				store.EXPECT().
+					GetTranslations(gomock.Any(), gomock.Any(), "de", false).
+					Do(func(_ context.Context, ids []bson.ObjectId, _ string, _ bool) {
+						require.Len(t, ids, projects.ExportPageSize)
+					}).
+					Return([]*model.Translation{translation(model.Draft, translator.ID)}, nil)
+				store.EXPECT().
+					GetMessages(gomock.Any(), &model.MessageQuery{ProjectID: site.ID, Cursor: page[len(page)-1].ID, Limit: projects.ExportPageSize}).
+					Return([]*model.Message{}, nil)
+
+				return store
+			},
+			user:     translator,
+			method:   "GET",
+			path:     "/projects/" + site.ID.Hex() + "/export/de",
+			code:     http.StatusOK,
+			contains: `[{"key":"hello","text":"Hallo","state":"draft"}]`,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(withNoopSpan)
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			r.Route("/projects", projects.Router(cfg, keys, store))
+
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			if c.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			st, err := auth.CreateToken(context.Background(), keys, store, c.user)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+st)
+			store.EXPECT().
+				IsTokenRevoked(gomock.Any(), gomock.Any()).
+				Return(false, nil)
+			store.EXPECT().
+				GetUserByID(gomock.Any(), c.user.ID).
+				Return(c.user, nil)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			if c.contains != "" {
+				assert.Contains(t, res.Body.String(), c.contains)
+			}
+		})
+	}
+}
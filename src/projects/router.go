@@ -0,0 +1,411 @@
+package projects
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/middleware"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Router return projects section router
+//
+// Projects are visible with any permission and created with CanAppend.
+// Only owner of project and admins can update, archive and restore it.
+// Messages are created and updated with CanAppend and deleted with CanDelete.
+// Translations are checked against binded languages of claims and
+// approved or rejected with CanReview
+func Router(cfg *config.Config, keys *auth.Keyring, store Store) func(chi.Router) {
+
+	return func(r chi.Router) {
+		r.Use(auth.WithClaims(cfg, keys, store, true, false))
+		r.Use(auth.WithPermission(model.CanEverything))
+		r.Use(middleware.JSONOnly)
+
+		r.Get("/", List(cfg, store))
+		r.With(auth.WithPermission(model.CanAppend)).Post("/", Create(cfg, store))
+		r.Get("/:id", Get(cfg, store))
+		r.Patch("/:id", Update(cfg, store))
+		r.Delete("/:id", Archive(cfg, store))
+		r.Post("/:id/restore", Restore(cfg, store))
+		r.Get("/:id/messages", Messages(cfg, store))
+		r.With(auth.WithPermission(model.CanAppend)).Post("/:id/messages", CreateMessage(cfg, store))
+		r.With(auth.WithPermission(model.CanAppend)).Post("/:id/messages/batch", CreateMessages(cfg, store))
+		r.With(auth.WithPermission(model.CanAppend)).Patch("/:id/messages/:key", UpdateMessage(cfg, store))
+		r.With(auth.WithPermission(model.CanDelete)).Delete("/:id/messages/:key", DeleteMessage(cfg, store))
+		r.Get("/:id/messages/:key/translations/:lang", GetTranslation(cfg, store))
+		r.Put("/:id/messages/:key/translations/:lang", PutTranslation(cfg, store))
+		r.Get("/:id/messages/:key/translations/:lang/history", History(cfg, store))
+		r.Get("/:id/messages/:key/translations/:lang/diff", Compare(cfg, store))
+		r.Post("/:id/messages/:key/translations/:lang/revert/:revision", Revert(cfg, store))
+		r.Post("/:id/messages/:key/translations/:lang/state", ChangeState(cfg, store))
+		r.Get("/:id/messages/:key/translations/:lang/transitions", Transitions(cfg, store))
+		r.Get("/:id/export/:lang", Export(cfg, store))
+	}
+}
+
+// objectID extract object id from url param
+func objectID(r *http.Request, param string) (bson.ObjectId, bool) {
+	id := chi.URLParam(r, param)
+	if !bson.IsObjectIdHex(id) {
+
+		return "", false
+	}
+
+	return bson.ObjectIdHex(id), true
+}
+
+// canManage return true if claims allow to change project
+func canManage(c *auth.Claims, p *model.Project) bool {
+	return c.IsAdmin || c.UserID == p.OwnerID
+}
+
+// normalizeLanguages check source and target languages of project
+//
+// Target languages must not contain source language
+func normalizeLanguages(source string, targets []string) (string, []string, error) {
+	source, ok := model.NormalizeLanguage(source)
+	if !ok {
+		return "", nil, fmt.Errorf("sourceLanguage: bad language tag: %s", source)
+	}
+	targets, err := model.NormalizeLanguages(targets)
+	if err != nil {
+		return "", nil, errors.WithMessage(err, "targetLanguages")
+	}
+	for _, lang := range targets {
+		if lang == source {
+			return "", nil, fmt.Errorf("targetLanguages: contain source language %s", source)
+		}
+	}
+
+	return source, targets, nil
+}
+
+// List of active projects or archived ones if query param archived is true
+func List(_ *config.Config, store Store) http.HandlerFunc {
+	// List of projects
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		pl, err := store.GetProjects(ctx, r.URL.Query().Get("archived") == "true")
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, pl)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Get project by id
+func Get(_ *config.Config, store Store) http.HandlerFunc {
+	// Get project by id
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad project id")
+			http.Error(w, "project not found", http.StatusNotFound)
+			return
+		}
+		p, err := store.GetProjectByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "project not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, p)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Create new project owned by current user
+func Create(_ *config.Config, store Store) http.HandlerFunc {
+	// Create new project
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Name            string   `json:"name" valid:"required"`
+			Slug            string   `json:"slug" valid:"required"`
+			Description     string   `json:"description"`
+			SourceLanguage  string   `json:"sourceLanguage" valid:"required"`
+			TargetLanguages []string `json:"targetLanguages"`
+			ApprovedOnly    bool     `json:"approvedOnly"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		if !model.ValidSlug(rd.Slug) {
+			l.Debug("bad slug")
+			http.Error(w, "validate: slug: bad slug", http.StatusBadRequest)
+			return
+		}
+		source, targets, err := normalizeLanguages(rd.SourceLanguage, rd.TargetLanguages)
+		if err != nil {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		c, _ := auth.ClaimsFromContext(ctx)
+		p := &model.Project{
+			ID:              bson.NewObjectId(),
+			Name:            rd.Name,
+			Slug:            rd.Slug,
+			Description:     rd.Description,
+			SourceLanguage:  source,
+			TargetLanguages: targets,
+			OwnerID:         c.UserID,
+			ApprovedOnly:    rd.ApprovedOnly,
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+		}
+		err = store.CreateProject(ctx, p)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "project already exists", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "project.create", p.ID.Hex(), nil, p)
+		l.Sugar().Infof("project <%s> created", p.Slug)
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, p)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Update project fields present in request
+//
+// Archived project can't be updated, only restored. Owner can be changed
+// only by admin to existing not deleted user
+func Update(_ *config.Config, store Store) http.HandlerFunc {
+	// Update project fields present in request
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad project id")
+			http.Error(w, "project not found", http.StatusNotFound)
+			return
+		}
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Name            *string        `json:"name"`
+			Slug            *string        `json:"slug"`
+			Description     *string        `json:"description"`
+			SourceLanguage  *string        `json:"sourceLanguage"`
+			TargetLanguages *[]string      `json:"targetLanguages"`
+			OwnerID         *bson.ObjectId `json:"ownerId"`
+			ApprovedOnly    *bool          `json:"approvedOnly"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if rd.Name != nil && *rd.Name == "" {
+			l.Debug("empty name")
+			http.Error(w, "validate: name: non zero value required", http.StatusBadRequest)
+			return
+		}
+		if rd.Slug != nil && !model.ValidSlug(*rd.Slug) {
+			l.Debug("bad slug")
+			http.Error(w, "validate: slug: bad slug", http.StatusBadRequest)
+			return
+		}
+		p, err := store.GetProjectByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "project not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		c, _ := auth.ClaimsFromContext(ctx)
+		if !canManage(c, p) {
+			l.Debug("not owner of project")
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if p.ArchivedAt != nil {
+			l.Debug("project is archived")
+			http.Error(w, "project is archived", http.StatusConflict)
+			return
+		}
+		before := *p
+		if rd.Name != nil {
+			p.Name = *rd.Name
+		}
+		if rd.Slug != nil {
+			p.Slug = *rd.Slug
+		}
+		if rd.Description != nil {
+			p.Description = *rd.Description
+		}
+		if rd.SourceLanguage != nil {
+			p.SourceLanguage = *rd.SourceLanguage
+		}
+		if rd.TargetLanguages != nil {
+			p.TargetLanguages = *rd.TargetLanguages
+		}
+		if rd.ApprovedOnly != nil {
+			p.ApprovedOnly = *rd.ApprovedOnly
+		}
+		p.SourceLanguage, p.TargetLanguages, err = normalizeLanguages(p.SourceLanguage, p.TargetLanguages)
+		if err != nil {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		if rd.OwnerID != nil {
+			if !c.IsAdmin {
+				l.Debug("owner changed not by admin")
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			if *rd.OwnerID != p.OwnerID {
+				_, err = store.GetUserByID(ctx, *rd.OwnerID)
+				if errors.Cause(err) == errs.ModelNotFound {
+					l.Debug(err.Error(), zap.Error(err))
+					http.Error(w, "validate: ownerId: user not found", http.StatusBadRequest)
+					return
+				} else if err != nil {
+					l.Error(err.Error(), errs.ZapStack(err))
+					http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+					return
+				}
+			}
+			p.OwnerID = *rd.OwnerID
+		}
+		p.UpdatedAt = time.Now()
+		err = store.UpdateProject(ctx, p)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "project already exists", http.StatusConflict)
+			return
+		} else if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "project not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "project.update", p.ID.Hex(), &before, p)
+		l.Sugar().Infof("project <%s> updated", p.Slug)
+		render.JSON(w, r, p)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Archive project
+func Archive(_ *config.Config, store Store) http.HandlerFunc {
+	// Archive project
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		setArchived(w, r, store, true)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Restore archived project
+func Restore(_ *config.Config, store Store) http.HandlerFunc {
+	// Restore archived project
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		setArchived(w, r, store, false)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// setArchived archive or restore project if current user can manage it
+func setArchived(w http.ResponseWriter, r *http.Request, store Store, archived bool) {
+	ctx := r.Context()
+	l := tracing.Logger(ctx)
+
+	id, ok := objectID(r, "id")
+	if !ok {
+		l.Debug("bad project id")
+		http.Error(w, "project not found", http.StatusNotFound)
+		return
+	}
+	p, err := store.GetProjectByID(ctx, id)
+	if errors.Cause(err) == errs.ModelNotFound {
+		l.Debug(err.Error(), zap.Error(err))
+		http.Error(w, "project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		l.Error(err.Error(), errs.ZapStack(err))
+		http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+	c, _ := auth.ClaimsFromContext(ctx)
+	if !canManage(c, p) {
+		l.Debug("not owner of project")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	action, state := "project.archive", "archived"
+	if archived {
+		err = store.ArchiveProject(ctx, id)
+	} else {
+		action, state = "project.restore", "restored"
+		err = store.RestoreProject(ctx, id)
+	}
+	if errors.Cause(err) == errs.ModelNotFound {
+		l.Debug(err.Error(), zap.Error(err))
+		if archived {
+			http.Error(w, "active project not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "archived project not found", http.StatusNotFound)
+		}
+		return
+	} else if err != nil {
+		l.Error(err.Error(), errs.ZapStack(err))
+		http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+	auth.Audit(ctx, store, r, action, id.Hex(), nil, nil)
+	l.Sugar().Infof("project <%s> %s", p.Slug, state)
+	http.Error(w, "project "+state, http.StatusOK)
+}
@@ -0,0 +1,366 @@
+package projects_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/projects"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pressly/chi"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func withNoopSpan(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		sp := opentracing.NoopTracer{}.StartSpan(r.URL.Path)
+		ctx := opentracing.ContextWithSpan(r.Context(), sp)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func TestRouter(t *testing.T) {
+	admin := &model.User{ID: bson.NewObjectId(), Email: "admin@email.com", IsAdmin: true}
+	owner := &model.User{ID: bson.NewObjectId(), Email: "owner@email.com", Permission: model.CanRead | model.CanAppend}
+	reader := &model.User{ID: bson.NewObjectId(), Email: "reader@email.com", Permission: model.CanRead}
+	nobody := &model.User{ID: bson.NewObjectId(), Email: "nobody@email.com"}
+	project := func() *model.Project {
+		return &model.Project{
+			ID:              bson.NewObjectId(),
+			Name:            "Site",
+			Slug:            "site",
+			SourceLanguage:  "en",
+			TargetLanguages: []string{"de"},
+			OwnerID:         owner.ID,
+		}
+	}
+	site := project()
+	archived := time.Now()
+
+	cases := []struct {
+		name   string
+		store  func(*gomock.Controller) *MockStore
+		user   *model.User
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{
+			name: "No permission",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   nobody,
+			method: "GET",
+			path:   "/projects",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "List archived",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjects(gomock.Any(), true).
+					Return([]*model.Project{site}, nil)
+
+				return store
+			},
+			user:   reader,
+			method: "GET",
+			path:   "/projects?archived=true",
+			code:   http.StatusOK,
+		},
+		{
+			name: "Get unknown",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			user:   reader,
+			method: "GET",
+			path:   "/projects/" + site.ID.Hex(),
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Create without append",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   reader,
+			method: "POST",
+			path:   "/projects",
+			body:   `{"name":"Site","slug":"site","sourceLanguage":"en"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Create bad slug",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   owner,
+			method: "POST",
+			path:   "/projects",
+			body:   `{"name":"Site","slug":"My Site","sourceLanguage":"en"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Create with source in targets",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   owner,
+			method: "POST",
+			path:   "/projects",
+			body:   `{"name":"Site","slug":"site","sourceLanguage":"en","targetLanguages":["de","EN"]}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Create",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					CreateProject(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, p *model.Project) {
+						require.Equal(t, "site", p.Slug)
+						require.Equal(t, owner.ID, p.OwnerID)
+						require.Equal(t, []string{"de", "pt-BR"}, p.TargetLanguages)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   owner,
+			method: "POST",
+			path:   "/projects",
+			body:   `{"name":"Site","slug":"site","sourceLanguage":"en","targetLanguages":["de","pt-br"]}`,
+			code:   http.StatusCreated,
+		},
+		{
+			name: "Create duplicate",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					CreateProject(gomock.Any(), gomock.Any()).
+					Return(errs.ModelExists)
+
+				return store
+			},
+			user:   owner,
+			method: "POST",
+			path:   "/projects",
+			body:   `{"name":"Site","slug":"site","sourceLanguage":"en"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Update not owner",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(project(), nil)
+
+				return store
+			},
+			user:   reader,
+			method: "PATCH",
+			path:   "/projects/" + site.ID.Hex(),
+			body:   `{"name":"Other"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Update archived",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				p := project()
+				p.ArchivedAt = &archived
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(p, nil)
+
+				return store
+			},
+			user:   owner,
+			method: "PATCH",
+			path:   "/projects/" + site.ID.Hex(),
+			body:   `{"name":"Other"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Update owner not by admin",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(project(), nil)
+
+				return store
+			},
+			user:   owner,
+			method: "PATCH",
+			path:   "/projects/" + site.ID.Hex(),
+			body:   `{"ownerId":"` + reader.ID.Hex() + `"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Update owner deleted",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(project(), nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), nobody.ID).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			user:   admin,
+			method: "PATCH",
+			path:   "/projects/" + site.ID.Hex(),
+			body:   `{"ownerId":"` + nobody.ID.Hex() + `"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Update owner",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(project(), nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), reader.ID).
+					Return(reader, nil)
+				store.EXPECT().
+					UpdateProject(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, p *model.Project) {
+						require.Equal(t, reader.ID, p.OwnerID)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "PATCH",
+			path:   "/projects/" + site.ID.Hex(),
+			body:   `{"ownerId":"` + reader.ID.Hex() + `"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Update",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(project(), nil)
+				store.EXPECT().
+					UpdateProject(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, p *model.Project) {
+						require.Equal(t, "Other", p.Name)
+						require.Equal(t, []string{"fr"}, p.TargetLanguages)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   owner,
+			method: "PATCH",
+			path:   "/projects/" + site.ID.Hex(),
+			body:   `{"name":"Other","targetLanguages":["fr"]}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Archive by admin",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(project(), nil)
+				store.EXPECT().
+					ArchiveProject(gomock.Any(), site.ID).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "DELETE",
+			path:   "/projects/" + site.ID.Hex(),
+			code:   http.StatusOK,
+		},
+		{
+			name: "Restore active",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(project(), nil)
+				store.EXPECT().
+					RestoreProject(gomock.Any(), site.ID).
+					Return(errs.ModelNotFound)
+
+				return store
+			},
+			user:   owner,
+			method: "POST",
+			path:   "/projects/" + site.ID.Hex() + "/restore",
+			body:   `{}`,
+			code:   http.StatusNotFound,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(withNoopSpan)
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			r.Route("/projects", projects.Router(cfg, keys, store))
+
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			if c.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			st, err := auth.CreateToken(context.Background(), keys, store, c.user)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+st)
+			store.EXPECT().
+				IsTokenRevoked(gomock.Any(), gomock.Any()).
+				Return(false, nil)
+			store.EXPECT().
+				GetUserByID(gomock.Any(), c.user.ID).
+				Return(c.user, nil)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package projects
+
+import (
+	"context"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/model"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+//go:generate mockgen -source=store.go -destination=store_mock_test.go -package=projects_test -aux_files=auth=../auth/store.go
+
+// Store is a interface of store required in package projects
+type Store interface {
+	auth.ClaimsStore
+	auth.AuditStore
+	GetProjects(context.Context, bool) ([]*model.Project, error)
+	GetProjectByID(context.Context, bson.ObjectId) (*model.Project, error)
+	CreateProject(context.Context, *model.Project) error
+	UpdateProject(context.Context, *model.Project) error
+	ArchiveProject(context.Context, bson.ObjectId) error
+	RestoreProject(context.Context, bson.ObjectId) error
+	GetMessages(context.Context, *model.MessageQuery) ([]*model.Message, error)
+	GetMessageByKey(context.Context, bson.ObjectId, string, string) (*model.Message, error)
+	CreateMessage(context.Context, *model.Message) error
+	CreateMessages(context.Context, []*model.Message) ([]int, error)
+	UpdateMessage(context.Context, *model.Message) error
+	DeleteMessage(context.Context, bson.ObjectId, bson.ObjectId) error
+	GetTranslation(context.Context, bson.ObjectId, string) (*model.Translation, error)
+	SaveTranslation(context.Context, *model.Translation, *model.TranslationRevision) error
+	GetTranslationRevisions(context.Context, bson.ObjectId, string) ([]*model.TranslationRevision, error)
+	GetTranslationRevision(context.Context, bson.ObjectId, string, int) (*model.TranslationRevision, error)
+	GetTranslations(context.Context, []bson.ObjectId, string, bool) ([]*model.Translation, error)
+	ChangeTranslationState(context.Context, *model.Translation, *model.TranslationTransition) error
+	CreateTranslationTransition(context.Context, *model.TranslationTransition) error
+	GetTranslationTransitions(context.Context, bson.ObjectId, string) ([]*model.TranslationTransition, error)
+}
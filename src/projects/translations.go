@@ -0,0 +1,369 @@
+package projects
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/diff"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// urlLanguage extract language tag from url param in canonical case
+// and check claims allow to read or edit translations on it
+//
+// Response is written if language is bad or not allowed
+func urlLanguage(w http.ResponseWriter, r *http.Request, edit bool) (string, bool) {
+	l := tracing.Logger(r.Context())
+
+	lang, ok := model.NormalizeLanguage(chi.URLParam(r, "lang"))
+	if !ok {
+		l.Debug("bad language")
+		http.Error(w, "validate: lang: bad language tag", http.StatusBadRequest)
+		return "", false
+	}
+	c, _ := auth.ClaimsFromContext(r.Context())
+	if !c.IsAdmin && (edit && !c.CanEditLanguage(lang) || !edit && !c.CanReadLanguage(lang)) {
+		l.Debug("no permission on language", zap.String("lang", lang), zap.Bool("edit", edit))
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return "", false
+	}
+
+	return lang, true
+}
+
+// isTarget return true if language is one of target languages of project
+func isTarget(p *model.Project, lang string) bool {
+	for _, target := range p.TargetLanguages {
+		if target == lang {
+			return true
+		}
+	}
+
+	return false
+}
+
+// saveTranslation of message on language as a new revision authored by current user
+//
+// Saved translation is a draft and transition to it is recorded if state is
+// changed. Response is written on error. Translation before change is nil if it is new
+func saveTranslation(w http.ResponseWriter, r *http.Request, store Store, msg *model.Message, lang, text string, revertedFrom int) (*model.Translation, *model.Translation, bool) {
+	ctx := r.Context()
+	l := tracing.Logger(ctx)
+
+	if utf8.RuneCountInString(text) > model.MaxTextLength {
+		l.Debug("translation exceed max text length")
+		http.Error(w, "validate: text: longer than "+strconv.Itoa(model.MaxTextLength)+" characters", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	if msg.MaxLength > 0 && utf8.RuneCountInString(text) > msg.MaxLength {
+		l.Debug("translation is too long")
+		http.Error(w, "validate: text: longer than "+strconv.Itoa(msg.MaxLength)+" characters", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	t, err := store.GetTranslation(ctx, msg.ID, lang)
+	var before *model.Translation
+	from := model.Untranslated
+	if errors.Cause(err) == errs.ModelNotFound {
+		t = &model.Translation{
+			ID:        bson.NewObjectId(),
+			ProjectID: msg.ProjectID,
+			MessageID: msg.ID,
+			Language:  lang,
+			CreatedAt: time.Now(),
+		}
+	} else if err != nil {
+		l.Error(err.Error(), errs.ZapStack(err))
+		http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+		return nil, nil, false
+	} else {
+		saved := *t
+		before = &saved
+		from = state(t)
+	}
+	c, _ := auth.ClaimsFromContext(ctx)
+	t.Text = text
+	t.Revision++
+	t.State = model.Draft
+	t.Reason = ""
+	t.AuthorID = c.UserID
+	t.AuthorService = c.Service
+	t.UpdatedAt = time.Now()
+	rev := &model.TranslationRevision{
+		ID:            bson.NewObjectId(),
+		MessageID:     msg.ID,
+		Language:      lang,
+		Number:        t.Revision,
+		Text:          text,
+		AuthorID:      t.AuthorID,
+		AuthorService: t.AuthorService,
+		RevertedFrom:  revertedFrom,
+		CreatedAt:     t.UpdatedAt,
+	}
+	err = store.SaveTranslation(ctx, t, rev)
+	if errors.Cause(err) == errs.ModelExists {
+		l.Debug(err.Error(), zap.Error(err))
+		http.Error(w, "translation changed concurrently", http.StatusConflict)
+		return nil, nil, false
+	} else if err != nil {
+		l.Error(err.Error(), errs.ZapStack(err))
+		http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if from != model.Draft {
+		err = store.CreateTranslationTransition(ctx, &model.TranslationTransition{
+			ID:           bson.NewObjectId(),
+			MessageID:    msg.ID,
+			Language:     lang,
+			From:         from,
+			To:           model.Draft,
+			ActorID:      c.UserID,
+			ActorService: c.Service,
+			CreatedAt:    t.UpdatedAt,
+		})
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+		}
+	}
+
+	return t, before, true
+}
+
+// GetTranslation of message on language
+//
+// CanRead allow only binded languages and CanReadAll every language
+func GetTranslation(_ *config.Config, store Store) http.HandlerFunc {
+	// Get translation of message on language
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		lang, ok := urlLanguage(w, r, false)
+		if !ok {
+			return
+		}
+		p, ok := urlProject(w, r, store, false)
+		if !ok {
+			return
+		}
+		msg, ok := projectMessage(w, r, store, p)
+		if !ok {
+			return
+		}
+		t, err := store.GetTranslation(ctx, msg.ID, lang)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "translation not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, t)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// PutTranslation of message on target language of active project
+//
+// CanEdit allow only binded languages and CanEditAll every language.
+// Every save is a new revision authored by current user
+func PutTranslation(_ *config.Config, store Store) http.HandlerFunc {
+	// Save translation of message on language
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Text string `json:"text" valid:"required"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		lang, ok := urlLanguage(w, r, true)
+		if !ok {
+			return
+		}
+		p, ok := urlProject(w, r, store, true)
+		if !ok {
+			return
+		}
+		if !isTarget(p, lang) {
+			l.Debug("not target language", zap.String("lang", lang))
+			http.Error(w, "validate: lang: not a target language of project", http.StatusBadRequest)
+			return
+		}
+		msg, ok := projectMessage(w, r, store, p)
+		if !ok {
+			return
+		}
+		t, before, ok := saveTranslation(w, r, store, msg, lang, rd.Text, 0)
+		if !ok {
+			return
+		}
+		auth.Audit(ctx, store, r, "translation.save", t.ID.Hex(), before, t)
+		l.Sugar().Infof("translation <%s> of message <%s> saved", lang, msg.Key)
+		render.JSON(w, r, t)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// History of translation revisions from newest to oldest
+func History(_ *config.Config, store Store) http.HandlerFunc {
+	// History of translation revisions
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		lang, ok := urlLanguage(w, r, false)
+		if !ok {
+			return
+		}
+		p, ok := urlProject(w, r, store, false)
+		if !ok {
+			return
+		}
+		msg, ok := projectMessage(w, r, store, p)
+		if !ok {
+			return
+		}
+		rl, err := store.GetTranslationRevisions(ctx, msg.ID, lang)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, rl)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Diff is a word level difference between two revisions of translation
+type Diff struct {
+	From    *model.TranslationRevision `json:"from"`
+	To      *model.TranslationRevision `json:"to"`
+	Changes []diff.Change              `json:"changes"`
+}
+
+// Compare two revisions of translation by words
+//
+// Revisions are numbers in query params from and to. Revisions, which differ
+// in too many words, are compared as a whole, see diff.MaxCells
+func Compare(_ *config.Config, store Store) http.HandlerFunc {
+	// Compare two revisions of translation
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		numbers := map[string]int{}
+		for _, param := range []string{"from", "to"} {
+			n, err := strconv.Atoi(r.URL.Query().Get(param))
+			if err != nil || n < 1 {
+				l.Debug("bad revision number", zap.String("param", param))
+				http.Error(w, "validate: "+param+": must be a revision number", http.StatusBadRequest)
+				return
+			}
+			numbers[param] = n
+		}
+		lang, ok := urlLanguage(w, r, false)
+		if !ok {
+			return
+		}
+		p, ok := urlProject(w, r, store, false)
+		if !ok {
+			return
+		}
+		msg, ok := projectMessage(w, r, store, p)
+		if !ok {
+			return
+		}
+		d := &Diff{}
+		for param, rev := range map[string]**model.TranslationRevision{"from": &d.From, "to": &d.To} {
+			var err error
+			*rev, err = store.GetTranslationRevision(ctx, msg.ID, lang, numbers[param])
+			if errors.Cause(err) == errs.ModelNotFound {
+				l.Debug(err.Error(), zap.Error(err))
+				http.Error(w, "revision not found", http.StatusNotFound)
+				return
+			} else if err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+		}
+		d.Changes = diff.Words(d.From.Text, d.To.Text)
+		render.JSON(w, r, d)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Revert translation to text of old revision
+//
+// Revert is a new revision authored by current user, so it can be reverted too
+func Revert(_ *config.Config, store Store) http.HandlerFunc {
+	// Revert translation to old revision
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		number, err := strconv.Atoi(chi.URLParam(r, "revision"))
+		if err != nil || number < 1 {
+			l.Debug("bad revision number")
+			http.Error(w, "revision not found", http.StatusNotFound)
+			return
+		}
+		lang, ok := urlLanguage(w, r, true)
+		if !ok {
+			return
+		}
+		p, ok := urlProject(w, r, store, true)
+		if !ok {
+			return
+		}
+		msg, ok := projectMessage(w, r, store, p)
+		if !ok {
+			return
+		}
+		rev, err := store.GetTranslationRevision(ctx, msg.ID, lang, number)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "revision not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		t, before, ok := saveTranslation(w, r, store, msg, lang, rev.Text, rev.Number)
+		if !ok {
+			return
+		}
+		auth.Audit(ctx, store, r, "translation.revert", t.ID.Hex(), before, t)
+		l.Sugar().Infof("translation <%s> of message <%s> reverted to revision %d", lang, msg.Key, rev.Number)
+		render.JSON(w, r, t)
+	}
+	return http.HandlerFunc(fn)
+}
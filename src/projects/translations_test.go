@@ -0,0 +1,445 @@
+package projects_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/projects"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pressly/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestTranslations(t *testing.T) {
+	translator := &model.User{ID: bson.NewObjectId(), Email: "translator@email.com", Permission: model.CanRead | model.CanEdit, Languages: []string{"de"}}
+	reader := &model.User{ID: bson.NewObjectId(), Email: "reader@email.com", Permission: model.CanRead, Languages: []string{"de"}}
+	allReader := &model.User{ID: bson.NewObjectId(), Email: "all.reader@email.com", Permission: model.CanReadAll}
+	allEditor := &model.User{ID: bson.NewObjectId(), Email: "all.editor@email.com", Permission: model.CanEditAll}
+	site := &model.Project{ID: bson.NewObjectId(), Slug: "site", SourceLanguage: "en", TargetLanguages: []string{"de", "fr"}}
+	hello := &model.Message{ID: bson.NewObjectId(), ProjectID: site.ID, Key: "hello", Text: "Hello", MaxLength: 8}
+	de := &model.Translation{ID: bson.NewObjectId(), ProjectID: site.ID, MessageID: hello.ID, Language: "de", Text: "Hallo"}
+	path := "/projects/" + site.ID.Hex() + "/messages/hello/translations/"
+
+	cases := []struct {
+		name     string
+		store    func(*gomock.Controller) *MockStore
+		user     *model.User
+		method   string
+		path     string
+		body     string
+		code     int
+		contains string
+	}{
+		{
+			name: "Get binded language",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(hello, nil)
+				store.EXPECT().
+					GetTranslation(gomock.Any(), hello.ID, "de").
+					Return(de, nil)
+
+				return store
+			},
+			user:   reader,
+			method: "GET",
+			path:   path + "DE",
+			code:   http.StatusOK,
+		},
+		{
+			name: "Get not binded language",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   reader,
+			method: "GET",
+			path:   path + "fr",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Get missing with read all",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(hello, nil)
+				store.EXPECT().
+					GetTranslation(gomock.Any(), hello.ID, "fr").
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			user:   allReader,
+			method: "GET",
+			path:   path + "fr",
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Put without edit",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   reader,
+			method: "PUT",
+			path:   path + "de",
+			body:   `{"text":"Hallo"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Put not binded language",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   translator,
+			method: "PUT",
+			path:   path + "fr",
+			body:   `{"text":"Bonjour"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Put not target language",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+
+				return store
+			},
+			user:   allEditor,
+			method: "PUT",
+			path:   path + "es",
+			body:   `{"text":"Hola"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Put too long",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(hello, nil)
+
+				return store
+			},
+			user:   translator,
+			method: "PUT",
+			path:   path + "de",
+			body:   `{"text":"Guten Tag!"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Put too long for any message",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "bye", "").
+					Return(&model.Message{ID: bson.NewObjectId(), ProjectID: site.ID, Key: "bye"}, nil)
+
+				return store
+			},
+			user:   allEditor,
+			method: "PUT",
+			path:   "/projects/" + site.ID.Hex() + "/messages/bye/translations/fr",
+			body:   `{"text":"` + strings.Repeat("a", model.MaxTextLength+1) + `"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Put new",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(hello, nil)
+				store.EXPECT().
+					GetTranslation(gomock.Any(), hello.ID, "fr").
+					Return(nil, errs.ModelNotFound)
+				store.EXPECT().
+					SaveTranslation(gomock.Any(), gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, tr *model.Translation, rev *model.TranslationRevision) {
+						require.Equal(t, "Bonjour", tr.Text)
+						require.Equal(t, 1, tr.Revision)
+						require.Equal(t, 1, rev.Number)
+						require.Equal(t, allEditor.ID, tr.AuthorID)
+						require.Equal(t, model.Draft, tr.State)
+						require.False(t, tr.UpdatedAt.IsZero())
+					}).
+					Return(nil)
+				store.EXPECT().
+					CreateTranslationTransition(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, tr *model.TranslationTransition) {
+						require.Equal(t, model.Untranslated, tr.From)
+						require.Equal(t, model.Draft, tr.To)
+						require.Equal(t, allEditor.ID, tr.ActorID)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   allEditor,
+			method: "PUT",
+			path:   path + "fr",
+			body:   `{"text":"Bonjour"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Put existing",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(hello, nil)
+				store.EXPECT().
+					GetTranslation(gomock.Any(), hello.ID, "de").
+					Return(&model.Translation{ID: de.ID, MessageID: hello.ID, Language: "de", Text: "Hallo", Revision: 2}, nil)
+				store.EXPECT().
+					SaveTranslation(gomock.Any(), gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, tr *model.Translation, rev *model.TranslationRevision) {
+						require.Equal(t, de.ID, tr.ID)
+						require.Equal(t, 3, rev.Number)
+						require.Equal(t, "Servus", rev.Text)
+						require.Equal(t, "Servus", tr.Text)
+						require.Equal(t, translator.ID, tr.AuthorID)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   translator,
+			method: "PUT",
+			path:   path + "de",
+			body:   `{"text":"Servus"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Put concurrently",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(hello, nil)
+				store.EXPECT().
+					GetTranslation(gomock.Any(), hello.ID, "de").
+					Return(&model.Translation{ID: de.ID, MessageID: hello.ID, Language: "de", Text: "Hallo", Revision: 2}, nil)
+				store.EXPECT().
+					SaveTranslation(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(errs.ModelExists)
+
+				return store
+			},
+			user:   translator,
+			method: "PUT",
+			path:   path + "de",
+			body:   `{"text":"Servus"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "History not binded language",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   reader,
+			method: "GET",
+			path:   path + "fr/history",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "History",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(hello, nil)
+				store.EXPECT().
+					GetTranslationRevisions(gomock.Any(), hello.ID, "de").
+					Return([]*model.TranslationRevision{{Number: 1, Text: "Hallo"}}, nil)
+
+				return store
+			},
+			user:   reader,
+			method: "GET",
+			path:   path + "de/history",
+			code:   http.StatusOK,
+		},
+		{
+			name: "Diff without revisions",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   reader,
+			method: "GET",
+			path:   path + "de/diff?from=1",
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Diff",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(hello, nil)
+				store.EXPECT().
+					GetTranslationRevision(gomock.Any(), hello.ID, "de", 1).
+					Return(&model.TranslationRevision{Number: 1, Text: "Hallo Welt"}, nil)
+				store.EXPECT().
+					GetTranslationRevision(gomock.Any(), hello.ID, "de", 2).
+					Return(&model.TranslationRevision{Number: 2, Text: "Hallo du"}, nil)
+
+				return store
+			},
+			user:     reader,
+			method:   "GET",
+			path:     path + "de/diff?from=1&to=2",
+			code:     http.StatusOK,
+			contains: `{"op":"delete","text":"Welt"},{"op":"insert","text":"du"}`,
+		},
+		{
+			name: "Revert unknown revision",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(hello, nil)
+				store.EXPECT().
+					GetTranslationRevision(gomock.Any(), hello.ID, "de", 7).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			user:   translator,
+			method: "POST",
+			path:   path + "de/revert/7",
+			body:   `{}`,
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Revert without edit",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   reader,
+			method: "POST",
+			path:   path + "de/revert/1",
+			body:   `{}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Revert",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetProjectByID(gomock.Any(), site.ID).
+					Return(site, nil)
+				store.EXPECT().
+					GetMessageByKey(gomock.Any(), site.ID, "hello", "").
+					Return(hello, nil)
+				store.EXPECT().
+					GetTranslationRevision(gomock.Any(), hello.ID, "de", 1).
+					Return(&model.TranslationRevision{Number: 1, Text: "Hallo"}, nil)
+				store.EXPECT().
+					GetTranslation(gomock.Any(), hello.ID, "de").
+					Return(&model.Translation{ID: de.ID, MessageID: hello.ID, Language: "de", Text: "Spam", Revision: 2}, nil)
+				store.EXPECT().
+					SaveTranslation(gomock.Any(), gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, tr *model.Translation, rev *model.TranslationRevision) {
+						require.Equal(t, "Hallo", tr.Text)
+						require.Equal(t, 3, rev.Number)
+						require.Equal(t, 1, rev.RevertedFrom)
+						require.Equal(t, translator.ID, rev.AuthorID)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   translator,
+			method: "POST",
+			path:   path + "de/revert/1",
+			body:   `{}`,
+			code:   http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(withNoopSpan)
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			r.Route("/projects", projects.Router(cfg, keys, store))
+
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			if c.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			st, err := auth.CreateToken(context.Background(), keys, store, c.user)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+st)
+			store.EXPECT().
+				IsTokenRevoked(gomock.Any(), gomock.Any()).
+				Return(false, nil)
+			store.EXPECT().
+				GetUserByID(gomock.Any(), c.user.ID).
+				Return(c.user, nil)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			if c.contains != "" {
+				assert.Contains(t, res.Body.String(), c.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package roles
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/middleware"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+)
+
+// roleName is a pattern of role name usable in url
+var roleName = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// Router return roles section router
+//
+// All routes are available only for admins
+func Router(cfg *config.Config, keys *auth.Keyring, store Store) func(chi.Router) {
+
+	return func(r chi.Router) {
+		r.Use(auth.WithClaims(cfg, keys, store, true, true))
+		r.Use(middleware.JSONOnly)
+
+		r.Get("/", List(cfg, store))
+		r.Post("/", Create(cfg, store))
+		r.Get("/:name", Get(cfg, store))
+		r.Patch("/:name", Update(cfg, store))
+		r.Delete("/:name", Delete(cfg, store))
+	}
+}
+
+// List of roles
+func List(_ *config.Config, store Store) http.HandlerFunc {
+	// List of roles
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		rl, err := store.GetRoles(ctx)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, rl)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Get role by name
+func Get(_ *config.Config, store Store) http.HandlerFunc {
+	// Get role by name
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		role, err := store.GetRoleByName(ctx, chi.URLParam(r, "name"))
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "role not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, role)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Create new role
+func Create(_ *config.Config, store Store) http.HandlerFunc {
+	// Create new role
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Name        string           `json:"name" valid:"required"`
+			Description string           `json:"description"`
+			Permission  model.Permission `json:"permission"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		if !roleName.MatchString(rd.Name) {
+			l.Debug("bad role name")
+			http.Error(w, "validate: name: must be lower case letters, digits, '-' or '_'", http.StatusBadRequest)
+			return
+		}
+		role := &model.Role{
+			Name:        rd.Name,
+			Description: rd.Description,
+			Permission:  rd.Permission & model.CanEverything,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+		err := store.CreateRole(ctx, role)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "role already exists", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "role.create", role.Name, nil, role)
+		l.Sugar().Infof("role <%s> created", role.Name)
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, role)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Update role fields present in request
+//
+// Tokens of users with role are revoked on update
+func Update(_ *config.Config, store Store) http.HandlerFunc {
+	// Update role fields present in request
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Description *string           `json:"description"`
+			Permission  *model.Permission `json:"permission"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		role, err := store.GetRoleByName(ctx, chi.URLParam(r, "name"))
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "role not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		before := *role
+		if rd.Description != nil {
+			role.Description = *rd.Description
+		}
+		if rd.Permission != nil {
+			role.Permission = *rd.Permission & model.CanEverything
+		}
+		role.UpdatedAt = time.Now()
+		err = store.UpdateRole(ctx, role)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "role not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "role.update", role.Name, &before, role)
+		l.Sugar().Infof("role <%s> updated", role.Name)
+		render.JSON(w, r, role)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Delete role and unassign it from users
+func Delete(_ *config.Config, store Store) http.HandlerFunc {
+	// Delete role and unassign it from users
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		name := chi.URLParam(r, "name")
+		err := store.DeleteRole(ctx, name)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "role not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "role.delete", name, nil, nil)
+		l.Sugar().Infof("role <%s> deleted", name)
+		http.Error(w, "role deleted", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,212 @@
+package roles_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/roles"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pressly/chi"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func withNoopSpan(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		sp := opentracing.NoopTracer{}.StartSpan(r.URL.Path)
+		ctx := opentracing.ContextWithSpan(r.Context(), sp)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func TestRouter(t *testing.T) {
+	admin := &model.User{ID: bson.NewObjectId(), Email: "admin@email.com", IsAdmin: true}
+	user := &model.User{ID: bson.NewObjectId(), Email: "user@email.com"}
+	translator := &model.Role{Name: "translator", Permission: model.CanRead | model.CanEdit}
+
+	cases := []struct {
+		name   string
+		store  func(*gomock.Controller) *MockStore
+		user   *model.User
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{
+			name: "Not admin",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   user,
+			method: "GET",
+			path:   "/roles",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "List",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetRoles(gomock.Any()).
+					Return([]*model.Role{translator}, nil)
+
+				return store
+			},
+			user:   admin,
+			method: "GET",
+			path:   "/roles",
+			code:   http.StatusOK,
+		},
+		{
+			name: "Get unknown",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetRoleByName(gomock.Any(), "unknown").
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			user:   admin,
+			method: "GET",
+			path:   "/roles/unknown",
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Create bad name",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/roles",
+			body:   `{"name":"Bad Name","permission":2}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Create duplicate",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					CreateRole(gomock.Any(), gomock.Any()).
+					Return(errs.ModelExists)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/roles",
+			body:   `{"name":"translator","permission":2}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Create",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					CreateRole(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, r *model.Role) {
+						require.Equal(t, "proofreader", r.Name)
+						require.Equal(t, model.CanReadAll, r.Permission)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/roles",
+			body:   `{"name":"proofreader","permission":4}`,
+			code:   http.StatusCreated,
+		},
+		{
+			name: "Update",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetRoleByName(gomock.Any(), "translator").
+					Return(&model.Role{Name: "translator", Permission: model.CanRead}, nil)
+				store.EXPECT().
+					UpdateRole(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, r *model.Role) {
+						require.Equal(t, model.CanRead|model.CanEdit, r.Permission)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "PATCH",
+			path:   "/roles/translator",
+			body:   `{"permission":10}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Delete",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					DeleteRole(gomock.Any(), "translator").
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "DELETE",
+			path:   "/roles/translator",
+			code:   http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(withNoopSpan)
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			r.Route("/roles", roles.Router(cfg, keys, store))
+
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			if c.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			st, err := auth.CreateToken(context.Background(), keys, store, c.user)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+st)
+			store.EXPECT().
+				IsTokenRevoked(gomock.Any(), gomock.Any()).
+				Return(false, nil)
+			store.EXPECT().
+				GetUserByID(gomock.Any(), c.user.ID).
+				Return(c.user, nil)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+		})
+	}
+}
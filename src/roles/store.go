@@ -0,0 +1,21 @@
+package roles
+
+import (
+	"context"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/model"
+)
+
+//go:generate mockgen -source=store.go -destination=store_mock_test.go -package=roles_test -aux_files=auth=../auth/store.go
+
+// Store is a interface of store required in package roles
+type Store interface {
+	auth.ClaimsStore
+	auth.AuditStore
+	GetRoles(context.Context) ([]*model.Role, error)
+	GetRoleByName(context.Context, string) (*model.Role, error)
+	CreateRole(context.Context, *model.Role) error
+	UpdateRole(context.Context, *model.Role) error
+	DeleteRole(context.Context, string) error
+}
@@ -4,10 +4,18 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/l10n-center/api/src/audit"
 	"github.com/l10n-center/api/src/auth"
 	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/groups"
+	"github.com/l10n-center/api/src/mail"
+	"github.com/l10n-center/api/src/me"
 	mw "github.com/l10n-center/api/src/middleware"
+	"github.com/l10n-center/api/src/projects"
+	"github.com/l10n-center/api/src/roles"
+	"github.com/l10n-center/api/src/serviceaccounts"
 	"github.com/l10n-center/api/src/tracing"
+	"github.com/l10n-center/api/src/users"
 
 	"github.com/pressly/chi"
 	"github.com/pressly/chi/docgen"
@@ -19,22 +27,36 @@ import (
 // Store is a combined interface to model store
 type Store interface {
 	auth.Store
+	users.Store
+	roles.Store
+	groups.Store
+	audit.Store
+	me.Store
+	serviceaccounts.Store
+	projects.Store
 }
 
-func router(cfg *config.Config, store Store) chi.Router {
+func router(cfg *config.Config, keys *auth.Keyring, authn auth.Authenticator, store Store, mailer mail.Mailer) chi.Router {
 	r := chi.NewRouter()
 
 	r.Use(tracing.WithSpan)
 	r.Use(mw.Boundary)
 
-	r.Route("/auth", auth.Router(cfg, store))
+	r.Route("/auth", auth.Router(cfg, keys, authn, store, mailer))
+	r.Route("/users", users.Router(cfg, keys, store, mailer))
+	r.Route("/roles", roles.Router(cfg, keys, store))
+	r.Route("/groups", groups.Router(cfg, keys, store))
+	r.Route("/audit", audit.Router(cfg, keys, store))
+	r.Route("/me", me.Router(cfg, keys, store, mailer))
+	r.Route("/service-accounts", serviceaccounts.Router(cfg, keys, store))
+	r.Route("/projects", projects.Router(cfg, keys, store))
 
 	return r
 }
 
 // NewRouter api router
-func NewRouter(cfg *config.Config, store Store) chi.Router {
-	api := router(cfg, store)
+func NewRouter(cfg *config.Config, keys *auth.Keyring, authn auth.Authenticator, store Store, mailer mail.Mailer) chi.Router {
+	api := router(cfg, keys, authn, store, mailer)
 
 	r := chi.NewRouter()
 
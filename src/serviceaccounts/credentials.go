@@ -0,0 +1,153 @@
+package serviceaccounts
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Credentials of service account
+func Credentials(_ *config.Config, store Store) http.HandlerFunc {
+	// Credentials of service account
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad service account id")
+			http.Error(w, "service account not found", http.StatusNotFound)
+			return
+		}
+		atl, err := store.GetAccessTokensByUser(ctx, id)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, atl)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// CreateCredential create access token of service account
+//
+// Token is returned only once in response and stored hashed.
+// It always has current permission of service account
+func CreateCredential(_ *config.Config, store Store) http.HandlerFunc {
+	// Create access token of service account
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad service account id")
+			http.Error(w, "service account not found", http.StatusNotFound)
+			return
+		}
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Name      string     `json:"name" valid:"required"`
+			ExpiresAt *time.Time `json:"expiresAt"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		if rd.ExpiresAt != nil && rd.ExpiresAt.Before(time.Now()) {
+			l.Debug("expiration in past")
+			http.Error(w, "validate: expiresAt: must be in future", http.StatusBadRequest)
+			return
+		}
+		sa, err := store.GetServiceAccountByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "service account not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		token, hash, err := auth.NewRandomToken()
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		at := &model.AccessToken{
+			ID:        bson.NewObjectId(),
+			UserID:    sa.ID,
+			Name:      rd.Name,
+			Hash:      hash,
+			Service:   true,
+			CreatedAt: time.Now(),
+			ExpiresAt: rd.ExpiresAt,
+		}
+		if err = store.CreateAccessToken(ctx, at); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "service.credential.create", sa.ID.Hex(), nil, at)
+		l.Sugar().Infof("credential <%s> of service account <%s> created", at.Name, sa.Name)
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, &struct {
+			*model.AccessToken
+			Token string `json:"token"`
+		}{at, auth.AccessTokenPrefix + token})
+	}
+	return http.HandlerFunc(fn)
+}
+
+// DeleteCredential revoke access token of service account
+func DeleteCredential(_ *config.Config, store Store) http.HandlerFunc {
+	// Revoke access token of service account
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		credentialID, cok := objectID(r, "credentialId")
+		if !ok || !cok {
+			l.Debug("bad service account or credential id")
+			http.Error(w, "credential not found", http.StatusNotFound)
+			return
+		}
+		err := store.DeleteAccessToken(ctx, id, credentialID)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "credential not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "service.credential.delete", id.Hex(), nil, map[string]string{"credentialId": credentialID.Hex()})
+		l.Sugar().Infof("credential <%s> of service account <%s> deleted", credentialID.Hex(), id.Hex())
+		http.Error(w, "credential deleted", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,316 @@
+package serviceaccounts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/middleware"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Router return service accounts section router
+//
+// All routes are available only for admins
+func Router(cfg *config.Config, keys *auth.Keyring, store Store) func(chi.Router) {
+
+	return func(r chi.Router) {
+		r.Use(auth.WithClaims(cfg, keys, store, true, true))
+		r.Use(middleware.JSONOnly)
+
+		r.Get("/", List(cfg, store))
+		r.Post("/", Create(cfg, store))
+		r.Get("/:id", Get(cfg, store))
+		r.Patch("/:id", Update(cfg, store))
+		r.Delete("/:id", Delete(cfg, store))
+		r.Get("/:id/credentials", Credentials(cfg, store))
+		r.Post("/:id/credentials", CreateCredential(cfg, store))
+		r.Delete("/:id/credentials/:credentialId", DeleteCredential(cfg, store))
+	}
+}
+
+// objectID extract object id from url param
+func objectID(r *http.Request, param string) (bson.ObjectId, bool) {
+	id := chi.URLParam(r, param)
+	if !bson.IsObjectIdHex(id) {
+
+		return "", false
+	}
+
+	return bson.ObjectIdHex(id), true
+}
+
+// checkOwner return false if owner is not an existing admin
+func checkOwner(ctx context.Context, store Store, id bson.ObjectId) (bool, error) {
+	u, err := store.GetUserByID(ctx, id)
+	if errors.Cause(err) == errs.ModelNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return u.IsAdmin, nil
+}
+
+// List of service accounts
+func List(_ *config.Config, store Store) http.HandlerFunc {
+	// List of service accounts
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		sal, err := store.GetServiceAccounts(ctx)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, sal)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Get service account by id
+func Get(_ *config.Config, store Store) http.HandlerFunc {
+	// Get service account by id
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad service account id")
+			http.Error(w, "service account not found", http.StatusNotFound)
+			return
+		}
+		sa, err := store.GetServiceAccountByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "service account not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, sa)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Create new service account
+//
+// Owner is a current admin if not set in request
+func Create(_ *config.Config, store Store) http.HandlerFunc {
+	// Create new service account
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Name        string           `json:"name" valid:"required"`
+			Description string           `json:"description"`
+			OwnerID     bson.ObjectId    `json:"ownerId"`
+			Permission  model.Permission `json:"permission"`
+			Languages   []string         `json:"languages"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		languages, err := model.NormalizeLanguages(rd.Languages)
+		if err != nil {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		if rd.OwnerID == "" {
+			c, _ := auth.ClaimsFromContext(ctx)
+			rd.OwnerID = c.UserID
+		} else {
+			ok, err := checkOwner(ctx, store, rd.OwnerID)
+			if err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				l.Debug("owner is not admin")
+				http.Error(w, "validate: ownerId: must be an admin", http.StatusBadRequest)
+				return
+			}
+		}
+		sa := &model.ServiceAccount{
+			ID:          bson.NewObjectId(),
+			Name:        rd.Name,
+			Description: rd.Description,
+			OwnerID:     rd.OwnerID,
+			Permission:  rd.Permission & model.CanEverything,
+			Languages:   languages,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+		err = store.CreateServiceAccount(ctx, sa)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "service account already exists", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "service.create", sa.ID.Hex(), nil, sa)
+		l.Sugar().Infof("service account <%s> created", sa.Name)
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, sa)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Update service account fields present in request
+func Update(_ *config.Config, store Store) http.HandlerFunc {
+	// Update service account fields present in request
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad service account id")
+			http.Error(w, "service account not found", http.StatusNotFound)
+			return
+		}
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Name        *string           `json:"name"`
+			Description *string           `json:"description"`
+			OwnerID     *bson.ObjectId    `json:"ownerId"`
+			Permission  *model.Permission `json:"permission"`
+			Languages   *[]string         `json:"languages"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if rd.Name != nil && *rd.Name == "" {
+			l.Debug("empty name")
+			http.Error(w, "validate: name: non zero value required", http.StatusBadRequest)
+			return
+		}
+		sa, err := store.GetServiceAccountByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "service account not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		before := *sa
+		if rd.Name != nil {
+			sa.Name = *rd.Name
+		}
+		if rd.Description != nil {
+			sa.Description = *rd.Description
+		}
+		if rd.OwnerID != nil {
+			ok, err = checkOwner(ctx, store, *rd.OwnerID)
+			if err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				l.Debug("owner is not admin")
+				http.Error(w, "validate: ownerId: must be an admin", http.StatusBadRequest)
+				return
+			}
+			sa.OwnerID = *rd.OwnerID
+		}
+		if rd.Permission != nil {
+			sa.Permission = *rd.Permission & model.CanEverything
+		}
+		if rd.Languages != nil {
+			sa.Languages, err = model.NormalizeLanguages(*rd.Languages)
+			if err != nil {
+				l.Debug(err.Error())
+				http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		sa.UpdatedAt = time.Now()
+		err = store.UpdateServiceAccount(ctx, sa)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "service account already exists", http.StatusConflict)
+			return
+		} else if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "service account not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "service.update", sa.ID.Hex(), &before, sa)
+		l.Sugar().Infof("service account <%s> updated", sa.Name)
+		render.JSON(w, r, sa)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Delete service account and revoke all it's credentials
+func Delete(_ *config.Config, store Store) http.HandlerFunc {
+	// Delete service account and revoke all it's credentials
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := objectID(r, "id")
+		if !ok {
+			l.Debug("bad service account id")
+			http.Error(w, "service account not found", http.StatusNotFound)
+			return
+		}
+		err := store.DeleteServiceAccount(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "service account not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "service.delete", id.Hex(), nil, nil)
+		l.Sugar().Infof("service account <%s> deleted", id.Hex())
+		http.Error(w, "service account deleted", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,270 @@
+package serviceaccounts_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/serviceaccounts"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pressly/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func withNoopSpan(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		sp := opentracing.NoopTracer{}.StartSpan(r.URL.Path)
+		ctx := opentracing.ContextWithSpan(r.Context(), sp)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func TestRouter(t *testing.T) {
+	admin := &model.User{ID: bson.NewObjectId(), Email: "admin@email.com", IsAdmin: true}
+	other := &model.User{ID: bson.NewObjectId(), Email: "other@email.com", IsAdmin: true}
+	user := &model.User{ID: bson.NewObjectId(), Email: "user@email.com"}
+	ci := &model.ServiceAccount{ID: bson.NewObjectId(), Name: "ci", OwnerID: admin.ID, Permission: model.CanRead | model.CanAppend}
+	credentialID := bson.NewObjectId()
+
+	cases := []struct {
+		name     string
+		store    func(*gomock.Controller) *MockStore
+		user     *model.User
+		method   string
+		path     string
+		body     string
+		code     int
+		contains string
+	}{
+		{
+			name: "Not admin",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   user,
+			method: "GET",
+			path:   "/service-accounts",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "List",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetServiceAccounts(gomock.Any()).
+					Return([]*model.ServiceAccount{ci}, nil)
+
+				return store
+			},
+			user:   admin,
+			method: "GET",
+			path:   "/service-accounts",
+			code:   http.StatusOK,
+		},
+		{
+			name: "Create",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					CreateServiceAccount(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, sa *model.ServiceAccount) {
+						require.Equal(t, "ci", sa.Name)
+						require.Equal(t, admin.ID, sa.OwnerID)
+						require.Equal(t, model.CanRead|model.CanAppend, sa.Permission)
+						require.Equal(t, []string{"de"}, sa.Languages)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/service-accounts",
+			body:   `{"name":"ci","permission":34,"languages":["de"]}`,
+			code:   http.StatusCreated,
+		},
+		{
+			name: "Create duplicate",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					CreateServiceAccount(gomock.Any(), gomock.Any()).
+					Return(errs.ModelExists)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/service-accounts",
+			body:   `{"name":"ci"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Create owned by not admin",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(user, nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/service-accounts",
+			body:   `{"name":"ci","ownerId":"` + user.ID.Hex() + `"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Update owner",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetServiceAccountByID(gomock.Any(), ci.ID).
+					Return(&model.ServiceAccount{ID: ci.ID, Name: ci.Name, OwnerID: admin.ID}, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), other.ID).
+					Return(other, nil)
+				store.EXPECT().
+					UpdateServiceAccount(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, sa *model.ServiceAccount) {
+						require.Equal(t, other.ID, sa.OwnerID)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "PATCH",
+			path:   "/service-accounts/" + ci.ID.Hex(),
+			body:   `{"ownerId":"` + other.ID.Hex() + `"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Delete unknown",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					DeleteServiceAccount(gomock.Any(), ci.ID).
+					Return(errs.ModelNotFound)
+
+				return store
+			},
+			user:   admin,
+			method: "DELETE",
+			path:   "/service-accounts/" + ci.ID.Hex(),
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Create credential",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetServiceAccountByID(gomock.Any(), ci.ID).
+					Return(ci, nil)
+				store.EXPECT().
+					CreateAccessToken(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, at *model.AccessToken) {
+						require.Equal(t, ci.ID, at.UserID)
+						require.True(t, at.Service)
+						require.NotEmpty(t, at.Hash)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:     admin,
+			method:   "POST",
+			path:     "/service-accounts/" + ci.ID.Hex() + "/credentials",
+			body:     `{"name":"deploy"}`,
+			code:     http.StatusCreated,
+			contains: `"token":"` + auth.AccessTokenPrefix,
+		},
+		{
+			name: "Create credential of unknown",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetServiceAccountByID(gomock.Any(), ci.ID).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/service-accounts/" + ci.ID.Hex() + "/credentials",
+			body:   `{"name":"deploy"}`,
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Delete credential",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					DeleteAccessToken(gomock.Any(), ci.ID, credentialID).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "DELETE",
+			path:   "/service-accounts/" + ci.ID.Hex() + "/credentials/" + credentialID.Hex(),
+			code:   http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(withNoopSpan)
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			r.Route("/service-accounts", serviceaccounts.Router(cfg, keys, store))
+
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			if c.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			st, err := auth.CreateToken(context.Background(), keys, store, c.user)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+st)
+			store.EXPECT().
+				IsTokenRevoked(gomock.Any(), gomock.Any()).
+				Return(false, nil)
+			store.EXPECT().
+				GetUserByID(gomock.Any(), c.user.ID).
+				Return(c.user, nil)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			if c.contains != "" {
+				assert.Contains(t, res.Body.String(), c.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package serviceaccounts
+
+import (
+	"context"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/model"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+//go:generate mockgen -source=store.go -destination=store_mock_test.go -package=serviceaccounts_test -aux_files=auth=../auth/store.go
+
+// Store is a interface of store required in package serviceaccounts
+type Store interface {
+	auth.ClaimsStore
+	auth.AuditStore
+	GetServiceAccounts(context.Context) ([]*model.ServiceAccount, error)
+	CreateServiceAccount(context.Context, *model.ServiceAccount) error
+	UpdateServiceAccount(context.Context, *model.ServiceAccount) error
+	DeleteServiceAccount(context.Context, bson.ObjectId) error
+	CreateAccessToken(context.Context, *model.AccessToken) error
+	GetAccessTokensByUser(context.Context, bson.ObjectId) ([]*model.AccessToken, error)
+	DeleteAccessToken(context.Context, bson.ObjectId, bson.ObjectId) error
+}
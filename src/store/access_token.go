@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const accessTokenCollection = "accessToken"
+
+func (s *Store) initAccessToken() error {
+	err := s.mongo.DB("").C(accessTokenCollection).EnsureIndex(mgo.Index{
+		Key:    []string{"hash"},
+		Unique: true,
+	})
+
+	if err == nil {
+		err = s.mongo.DB("").C(accessTokenCollection).EnsureIndex(mgo.Index{
+			Key: []string{"userId"},
+		})
+	}
+
+	return errors.WithStack(err)
+}
+
+// CreateAccessToken insert new personal access token
+func (s *Store) CreateAccessToken(ctx context.Context, at *model.AccessToken) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateAccessToken")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	return errors.WithStack(m.DB("").C(accessTokenCollection).Insert(at))
+}
+
+// GetAccessTokensByUser return all personal access tokens of user
+func (s *Store) GetAccessTokensByUser(ctx context.Context, userID bson.ObjectId) ([]*model.AccessToken, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetAccessTokensByUser")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	atl := []*model.AccessToken{}
+
+	err := m.DB("").C(accessTokenCollection).Find(bson.M{"userId": userID}).Sort("createdAt").All(&atl)
+
+	return atl, errors.WithStack(err)
+}
+
+// GetAccessTokenByHash search personal access token by hash
+func (s *Store) GetAccessTokenByHash(ctx context.Context, hash []byte) (*model.AccessToken, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetAccessTokenByHash")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	at := &model.AccessToken{}
+
+	err := m.DB("").C(accessTokenCollection).Find(bson.M{"hash": hash}).One(at)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return at, errors.WithStack(err)
+}
+
+// TouchAccessToken save last usage time of personal access token
+func (s *Store) TouchAccessToken(ctx context.Context, id bson.ObjectId, at time.Time) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:TouchAccessToken")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(accessTokenCollection).UpdateId(id, bson.M{"$set": bson.M{"lastUsedAt": at}})
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// DeleteAccessToken remove personal access token of user
+func (s *Store) DeleteAccessToken(ctx context.Context, userID, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:DeleteAccessToken")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(accessTokenCollection).Remove(bson.M{"_id": id, "userId": userID})
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const auditCollection = "audit"
+
+func (s *Store) initAudit() error {
+	err := s.mongo.DB("").C(auditCollection).EnsureIndex(mgo.Index{
+		Key:    []string{"actorId", "-_id"},
+		Sparse: true,
+	})
+
+	if err == nil {
+		err = s.mongo.DB("").C(auditCollection).EnsureIndex(mgo.Index{
+			Key: []string{"action", "-_id"},
+		})
+	}
+
+	if err == nil {
+		err = s.mongo.DB("").C(auditCollection).EnsureIndex(mgo.Index{
+			Key: []string{"createdAt"},
+		})
+	}
+
+	return errors.WithStack(err)
+}
+
+// CreateAuditEntry append entry to audit log
+//
+// Audit log is append only, so there are no methods to change entries
+func (s *Store) CreateAuditEntry(ctx context.Context, e *model.AuditEntry) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateAuditEntry")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	return errors.WithStack(m.DB("").C(auditCollection).Insert(e))
+}
+
+// GetAuditEntries return page of entries matched query from newest to oldest
+func (s *Store) GetAuditEntries(ctx context.Context, q *model.AuditQuery) ([]*model.AuditEntry, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetAuditEntries")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	filter := bson.M{}
+	if q.ActorID != "" {
+		filter["actorId"] = q.ActorID
+	}
+	if q.Action != "" {
+		filter["action"] = q.Action
+	}
+	if q.Cursor != "" {
+		filter["_id"] = bson.M{"$lt": q.Cursor}
+	}
+	createdAt := bson.M{}
+	if q.From != nil {
+		createdAt["$gte"] = *q.From
+	}
+	if q.To != nil {
+		createdAt["$lt"] = *q.To
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+
+	el := []*model.AuditEntry{}
+
+	err := m.DB("").C(auditCollection).Find(filter).Sort("-_id").Limit(q.Limit).All(&el)
+
+	return el, errors.WithStack(err)
+}
@@ -0,0 +1,259 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const groupCollection = "group"
+
+func (s *Store) initGroup() error {
+	err := s.mongo.DB("").C(groupCollection).EnsureIndex(mgo.Index{
+		Key:    []string{"name"},
+		Unique: true,
+	})
+
+	if err == nil {
+		err = s.mongo.DB("").C(userCollection).EnsureIndex(mgo.Index{
+			Key: []string{"groups"},
+		})
+	}
+
+	return errors.WithStack(err)
+}
+
+// GetGroups return all groups ordered by name
+func (s *Store) GetGroups(ctx context.Context) ([]*model.Group, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetGroups")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	gl := []*model.Group{}
+
+	err := m.DB("").C(groupCollection).Find(nil).Sort("name").All(&gl)
+
+	return gl, errors.WithStack(err)
+}
+
+// GetGroupsByIDs return existing groups with ids
+func (s *Store) GetGroupsByIDs(ctx context.Context, ids []bson.ObjectId) ([]*model.Group, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetGroupsByIDs")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	gl := []*model.Group{}
+
+	err := m.DB("").C(groupCollection).Find(bson.M{"_id": bson.M{"$in": ids}}).All(&gl)
+
+	return gl, errors.WithStack(err)
+}
+
+// GetGroupByID search group by id
+func (s *Store) GetGroupByID(ctx context.Context, id bson.ObjectId) (*model.Group, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetGroupByID")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	g := &model.Group{}
+
+	err := m.DB("").C(groupCollection).FindId(id).One(g)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return g, errors.WithStack(err)
+}
+
+// CreateGroup insert new group
+func (s *Store) CreateGroup(ctx context.Context, g *model.Group) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateGroup")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(groupCollection).Insert(g)
+
+	if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// UpdateGroup replace stored group by id
+//
+// Tokens of members are revoked only if permission or languages
+// of group are changed, because only they are inherited to claims.
+func (s *Store) UpdateGroup(ctx context.Context, g *model.Group) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:UpdateGroup")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	old := &model.Group{}
+
+	_, err := m.DB("").C(groupCollection).FindId(g.ID).Apply(mgo.Change{Update: g}, old)
+
+	if err == mgo.ErrNotFound {
+		return errors.WithStack(errs.ModelNotFound)
+	} else if mgo.IsDup(err) {
+		return errors.WithStack(errs.ModelExists)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if old.Permission == g.Permission && sameLanguages(old.Languages, g.Languages) {
+		return nil
+	}
+
+	_, err = m.DB("").C(userCollection).UpdateAll(
+		bson.M{"groups": g.ID},
+		bson.M{"$inc": bson.M{"tokenVersion": 1}},
+	)
+
+	return errors.WithStack(err)
+}
+
+// DeleteGroup remove group, remove it's members and revoke their tokens
+func (s *Store) DeleteGroup(ctx context.Context, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:DeleteGroup")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(groupCollection).RemoveId(id)
+
+	if err == mgo.ErrNotFound {
+		return errors.WithStack(errs.ModelNotFound)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = m.DB("").C(userCollection).UpdateAll(
+		bson.M{"groups": id},
+		bson.M{
+			"$pull": bson.M{"groups": id},
+			"$set":  bson.M{"updatedAt": time.Now()},
+			"$inc":  bson.M{"tokenVersion": 1},
+		},
+	)
+
+	return errors.WithStack(err)
+}
+
+// GetGroupMembers return not deleted members of group ordered by email
+func (s *Store) GetGroupMembers(ctx context.Context, id bson.ObjectId) ([]*model.User, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetGroupMembers")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	ul := []*model.User{}
+
+	err := m.DB("").C(userCollection).Find(bson.M{"groups": id, "deletedAt": nil}).Sort("email").All(&ul)
+
+	return ul, errors.WithStack(err)
+}
+
+// AddGroupMember add not deleted user to group and revoke it's tokens
+func (s *Store) AddGroupMember(ctx context.Context, id, userID bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:AddGroupMember")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": userID, "groups": bson.M{"$ne": id}, "deletedAt": nil},
+		bson.M{
+			"$push": bson.M{"groups": id},
+			"$set":  bson.M{"updatedAt": time.Now()},
+			"$inc":  bson.M{"tokenVersion": 1},
+		},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// RemoveGroupMember remove user from group and revoke it's tokens
+func (s *Store) RemoveGroupMember(ctx context.Context, id, userID bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:RemoveGroupMember")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": userID, "groups": id},
+		bson.M{
+			"$pull": bson.M{"groups": id},
+			"$set":  bson.M{"updatedAt": time.Now()},
+			"$inc":  bson.M{"tokenVersion": 1},
+		},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// sameLanguages compare lists of languages as sets, so order
+// and duplicates don't matter and nil is the same as empty
+func sameLanguages(a, b []string) bool {
+	as := make(map[string]bool, len(a))
+	for _, l := range a {
+		as[l] = true
+	}
+	bs := make(map[string]bool, len(b))
+	for _, l := range b {
+		if !as[l] {
+			return false
+		}
+		bs[l] = true
+	}
+
+	return len(as) == len(bs)
+}
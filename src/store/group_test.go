@@ -0,0 +1,30 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameLanguages(t *testing.T) {
+	cases := []struct {
+		name string
+		a    []string
+		b    []string
+		same bool
+	}{
+		{name: "Empty", a: nil, b: []string{}, same: true},
+		{name: "Equal", a: []string{"en", "ru"}, b: []string{"en", "ru"}, same: true},
+		{name: "Reordered", a: []string{"en", "ru"}, b: []string{"ru", "en"}, same: true},
+		{name: "Duplicated", a: []string{"en", "ru"}, b: []string{"ru", "en", "ru"}, same: true},
+		{name: "Added", a: []string{"en"}, b: []string{"en", "ru"}},
+		{name: "Removed", a: []string{"en", "ru"}, b: []string{"en"}},
+		{name: "Replaced", a: []string{"en", "ru"}, b: []string{"en", "de"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.same, sameLanguages(c.a, c.b))
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const loginThrottleCollection = "loginThrottle"
+
+func (s *Store) initLoginThrottle() error {
+	err := s.mongo.DB("").C(loginThrottleCollection).EnsureIndex(mgo.Index{
+		Key:         []string{"expiresAt"},
+		ExpireAfter: time.Second,
+	})
+
+	return errors.WithStack(err)
+}
+
+// FailLogin increment counter by key and return it's state before
+// increment, which is empty for new counter
+//
+// Expired counter is started again
+func (s *Store) FailLogin(ctx context.Context, key string, at time.Time, expiresAt time.Time) (*model.LoginThrottle, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:FailLogin")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	c := m.DB("").C(loginThrottleCollection)
+
+	err := c.Remove(bson.M{"_id": key, "expiresAt": bson.M{"$lte": at}})
+	if err != nil && err != mgo.ErrNotFound {
+		return nil, errors.WithStack(err)
+	}
+
+	lt := &model.LoginThrottle{}
+
+	_, err = c.FindId(key).Apply(mgo.Change{
+		Update: bson.M{
+			"$inc": bson.M{"failures": 1},
+			"$set": bson.M{
+				"lastFailure": at,
+				"expiresAt":   expiresAt,
+			},
+		},
+		Upsert: true,
+	}, lt)
+
+	return lt, errors.WithStack(err)
+}
+
+// GetLoginThrottles return not expired counters by keys
+func (s *Store) GetLoginThrottles(ctx context.Context, keys []string) ([]*model.LoginThrottle, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetLoginThrottles")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	ltl := []*model.LoginThrottle{}
+
+	err := m.DB("").C(loginThrottleCollection).Find(bson.M{
+		"_id":       bson.M{"$in": keys},
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).All(&ltl)
+
+	return ltl, errors.WithStack(err)
+}
+
+// ReleaseLogin decrement not empty counter by key
+func (s *Store) ReleaseLogin(ctx context.Context, key string) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:ReleaseLogin")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(loginThrottleCollection).Update(
+		bson.M{"_id": key, "failures": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"failures": -1}},
+	)
+	if err == mgo.ErrNotFound {
+		err = nil
+	}
+
+	return errors.WithStack(err)
+}
+
+// DeleteLoginThrottles remove counters by keys
+func (s *Store) DeleteLoginThrottles(ctx context.Context, keys []string) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:DeleteLoginThrottles")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	_, err := m.DB("").C(loginThrottleCollection).RemoveAll(bson.M{"_id": bson.M{"$in": keys}})
+
+	return errors.WithStack(err)
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const messageCollection = "message"
+
+func (s *Store) initMessage() error {
+	err := s.mongo.DB("").C(messageCollection).EnsureIndex(mgo.Index{
+		Key:    []string{"projectId", "key", "context"},
+		Unique: true,
+	})
+
+	if err == nil {
+		err = s.mongo.DB("").C(messageCollection).EnsureIndex(mgo.Index{
+			Key: []string{"projectId", "tags"},
+		})
+	}
+
+	return errors.WithStack(err)
+}
+
+// GetMessages return messages of project filtered by query in order of creation
+func (s *Store) GetMessages(ctx context.Context, q *model.MessageQuery) ([]*model.Message, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetMessages")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	filter := bson.M{"projectId": q.ProjectID}
+	if q.KeyPrefix != "" {
+		filter["key"] = bson.RegEx{Pattern: "^" + regexp.QuoteMeta(q.KeyPrefix)}
+	}
+	if q.Context != nil {
+		filter["context"] = *q.Context
+	}
+	if q.Tag != "" {
+		filter["tags"] = q.Tag
+	}
+	if q.Search != "" {
+		re := bson.RegEx{Pattern: regexp.QuoteMeta(q.Search), Options: "i"}
+		filter["$or"] = []bson.M{{"key": re}, {"text": re}}
+	}
+	if q.Cursor != "" {
+		filter["_id"] = bson.M{"$gt": q.Cursor}
+	}
+
+	ml := []*model.Message{}
+
+	err := m.DB("").C(messageCollection).Find(filter).Sort("_id").Limit(q.Limit).All(&ml)
+
+	return ml, errors.WithStack(err)
+}
+
+// GetMessageByKey search message of project by key and context
+func (s *Store) GetMessageByKey(ctx context.Context, projectID bson.ObjectId, key, context string) (*model.Message, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetMessageByKey")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	msg := &model.Message{}
+
+	err := m.DB("").C(messageCollection).Find(bson.M{"projectId": projectID, "key": key, "context": context}).One(msg)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return msg, errors.WithStack(err)
+}
+
+// CreateMessage insert new message
+func (s *Store) CreateMessage(ctx context.Context, msg *model.Message) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateMessage")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(messageCollection).Insert(msg)
+
+	if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// CreateMessages insert batch of messages and return indexes of messages,
+// which are skipped because of existing ones with same key and context
+func (s *Store) CreateMessages(ctx context.Context, ml []*model.Message) ([]int, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateMessages")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	b := m.DB("").C(messageCollection).Bulk()
+	b.Unordered()
+	for _, msg := range ml {
+		b.Insert(msg)
+	}
+
+	_, err := b.Run()
+
+	skipped := []int{}
+	if be, ok := err.(*mgo.BulkError); ok {
+		for _, c := range be.Cases() {
+			if !mgo.IsDup(c.Err) || c.Index < 0 {
+				return nil, errors.WithStack(c.Err)
+			}
+			skipped = append(skipped, c.Index)
+		}
+		err = nil
+	}
+
+	return skipped, errors.WithStack(err)
+}
+
+// UpdateMessage replace stored message by id
+func (s *Store) UpdateMessage(ctx context.Context, msg *model.Message) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:UpdateMessage")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(messageCollection).Update(bson.M{"_id": msg.ID, "projectId": msg.ProjectID}, msg)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	} else if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// DeleteMessage remove message of project with all it's translations,
+// their revisions and transitions
+func (s *Store) DeleteMessage(ctx context.Context, projectID, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:DeleteMessage")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(messageCollection).Remove(bson.M{"_id": id, "projectId": projectID})
+
+	if err == mgo.ErrNotFound {
+		return errors.WithStack(errs.ModelNotFound)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = m.DB("").C(translationCollection).RemoveAll(bson.M{"messageId": id})
+
+	if err == nil {
+		_, err = m.DB("").C(translationRevisionCollection).RemoveAll(bson.M{"messageId": id})
+	}
+
+	if err == nil {
+		_, err = m.DB("").C(translationTransitionCollection).RemoveAll(bson.M{"messageId": id})
+	}
+
+	return errors.WithStack(err)
+}
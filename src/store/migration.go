@@ -0,0 +1,36 @@
+package store
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const migrationCollection = "migration"
+
+// migrate apply fn to database once by name
+//
+// Migration is marked applied before fn, so concurrently started instance
+// skip it, and mark is removed if fn fails to retry it on next start
+func (s *Store) migrate(name string, fn func(*mgo.Database) error) error {
+	db := s.mongo.DB("")
+
+	err := db.C(migrationCollection).Insert(bson.M{"_id": name, "appliedAt": time.Now()})
+	if mgo.IsDup(err) {
+		return nil
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err = fn(db); err != nil {
+		if rerr := db.C(migrationCollection).RemoveId(name); rerr != nil {
+			return errors.Wrap(err, rerr.Error())
+		}
+
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"os"
+	"testing"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// testConfig return config with empty test database or skip test
+// if L10NC_TEST_MONGO_HOST is not set
+func testConfig(t *testing.T) *config.Config {
+	host := os.Getenv("L10NC_TEST_MONGO_HOST")
+	if host == "" {
+		t.Skip("L10NC_TEST_MONGO_HOST is not set")
+	}
+
+	cfg := config.Default()
+	cfg.MongoHost = host
+	cfg.MongoDB = "l10n_center_test_" + bson.NewObjectId().Hex()
+
+	return cfg
+}
+
+func TestMigrateUserEmailVerified(t *testing.T) {
+	cfg := testConfig(t)
+
+	// Database of version without email verification
+	legacy := &model.User{ID: bson.NewObjectId(), Email: "legacy@email.com"}
+	pending := &model.User{ID: bson.NewObjectId(), Email: "pending@email.com", PendingEmail: "pending@email.com"}
+	m, err := mgo.Dial(cfg.MongoHost + "/" + cfg.MongoDB)
+	require.NoError(t, err)
+
+	defer m.Close()
+
+	defer m.DB("").DropDatabase()
+
+	require.NoError(t, m.DB("").C(userCollection).Insert(legacy, pending))
+
+	s, err := New(cfg)
+	require.NoError(t, err)
+	s.Close()
+
+	u := &model.User{}
+	require.NoError(t, m.DB("").C(userCollection).FindId(legacy.ID).One(u))
+	assert.NotNil(t, u.EmailVerifiedAt, "legacy user is verified")
+	u = &model.User{}
+	require.NoError(t, m.DB("").C(userCollection).FindId(pending.ID).One(u))
+	assert.Nil(t, u.EmailVerifiedAt, "pending user is not verified")
+
+	// Migration is not applied on next start
+	require.NoError(t, m.DB("").C(userCollection).UpdateId(legacy.ID, bson.M{"$unset": bson.M{"emailVerifiedAt": ""}}))
+
+	s, err = New(cfg)
+	require.NoError(t, err)
+	s.Close()
+
+	u = &model.User{}
+	require.NoError(t, m.DB("").C(userCollection).FindId(legacy.ID).One(u))
+	assert.Nil(t, u.EmailVerifiedAt, "migration is applied once")
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const projectCollection = "project"
+
+func (s *Store) initProject() error {
+	err := s.mongo.DB("").C(projectCollection).EnsureIndex(mgo.Index{
+		Key:    []string{"slug"},
+		Unique: true,
+	})
+
+	return errors.WithStack(err)
+}
+
+// GetProjects return active or archived projects ordered by name
+func (s *Store) GetProjects(ctx context.Context, archived bool) ([]*model.Project, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetProjects")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	pl := []*model.Project{}
+	q := bson.M{"archivedAt": nil}
+	if archived {
+		q = bson.M{"archivedAt": bson.M{"$ne": nil}}
+	}
+
+	err := m.DB("").C(projectCollection).Find(q).Sort("name").All(&pl)
+
+	return pl, errors.WithStack(err)
+}
+
+// GetProjectByID search project by id including archived
+func (s *Store) GetProjectByID(ctx context.Context, id bson.ObjectId) (*model.Project, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetProjectByID")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	p := &model.Project{}
+
+	err := m.DB("").C(projectCollection).FindId(id).One(p)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return p, errors.WithStack(err)
+}
+
+// CreateProject insert new project
+func (s *Store) CreateProject(ctx context.Context, p *model.Project) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateProject")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(projectCollection).Insert(p)
+
+	if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// UpdateProject replace stored project by id
+func (s *Store) UpdateProject(ctx context.Context, p *model.Project) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:UpdateProject")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(projectCollection).UpdateId(p.ID, p)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	} else if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// ArchiveProject mark active project as archived
+func (s *Store) ArchiveProject(ctx context.Context, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:ArchiveProject")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	now := time.Now()
+	err := m.DB("").C(projectCollection).Update(
+		bson.M{"_id": id, "archivedAt": nil},
+		bson.M{"$set": bson.M{"archivedAt": now, "updatedAt": now}},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// RestoreProject remove archived mark from project
+func (s *Store) RestoreProject(ctx context.Context, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:RestoreProject")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(projectCollection).Update(
+		bson.M{"_id": id, "archivedAt": bson.M{"$ne": nil}},
+		bson.M{
+			"$set":   bson.M{"updatedAt": time.Now()},
+			"$unset": bson.M{"archivedAt": ""},
+		},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
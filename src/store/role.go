@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const roleCollection = "role"
+
+// initRole create default roles, which are not exist
+func (s *Store) initRole() error {
+	now := time.Now()
+	for _, r := range model.DefaultRoles {
+		_, err := s.mongo.DB("").C(roleCollection).UpsertId(r.Name, bson.M{"$setOnInsert": bson.M{
+			"description": r.Description,
+			"permission":  r.Permission,
+			"createdAt":   now,
+			"updatedAt":   now,
+		}})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// GetRoles return all roles ordered by name
+func (s *Store) GetRoles(ctx context.Context) ([]*model.Role, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetRoles")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	rl := []*model.Role{}
+
+	err := m.DB("").C(roleCollection).Find(nil).Sort("_id").All(&rl)
+
+	return rl, errors.WithStack(err)
+}
+
+// GetRolesByNames return existing roles with names
+func (s *Store) GetRolesByNames(ctx context.Context, names []string) ([]*model.Role, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetRolesByNames")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	rl := []*model.Role{}
+
+	err := m.DB("").C(roleCollection).Find(bson.M{"_id": bson.M{"$in": names}}).All(&rl)
+
+	return rl, errors.WithStack(err)
+}
+
+// GetRoleByName search role by name
+func (s *Store) GetRoleByName(ctx context.Context, name string) (*model.Role, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetRoleByName")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	r := &model.Role{}
+
+	err := m.DB("").C(roleCollection).FindId(name).One(r)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return r, errors.WithStack(err)
+}
+
+// CreateRole insert new role
+func (s *Store) CreateRole(ctx context.Context, r *model.Role) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateRole")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(roleCollection).Insert(r)
+
+	if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// UpdateRole replace stored role by name and revoke tokens of users
+// with role, which contain old permission
+func (s *Store) UpdateRole(ctx context.Context, r *model.Role) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:UpdateRole")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(roleCollection).UpdateId(r.Name, r)
+
+	if err == mgo.ErrNotFound {
+		return errors.WithStack(errs.ModelNotFound)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = m.DB("").C(userCollection).UpdateAll(
+		bson.M{"roles": r.Name},
+		bson.M{"$inc": bson.M{"tokenVersion": 1}},
+	)
+
+	return errors.WithStack(err)
+}
+
+// DeleteRole remove role, unassign it from users and revoke their tokens
+func (s *Store) DeleteRole(ctx context.Context, name string) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:DeleteRole")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(roleCollection).RemoveId(name)
+
+	if err == mgo.ErrNotFound {
+		return errors.WithStack(errs.ModelNotFound)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = m.DB("").C(userCollection).UpdateAll(
+		bson.M{"roles": name},
+		bson.M{
+			"$pull": bson.M{"roles": name},
+			"$set":  bson.M{"updatedAt": time.Now()},
+			"$inc":  bson.M{"tokenVersion": 1},
+		},
+	)
+
+	return errors.WithStack(err)
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const serviceAccountCollection = "serviceAccount"
+
+func (s *Store) initServiceAccount() error {
+	err := s.mongo.DB("").C(serviceAccountCollection).EnsureIndex(mgo.Index{
+		Key:    []string{"name"},
+		Unique: true,
+	})
+
+	if err == nil {
+		err = s.mongo.DB("").C(serviceAccountCollection).EnsureIndex(mgo.Index{
+			Key: []string{"ownerId"},
+		})
+	}
+
+	return errors.WithStack(err)
+}
+
+// GetServiceAccounts return all service accounts ordered by name
+func (s *Store) GetServiceAccounts(ctx context.Context) ([]*model.ServiceAccount, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetServiceAccounts")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	sal := []*model.ServiceAccount{}
+
+	err := m.DB("").C(serviceAccountCollection).Find(nil).Sort("name").All(&sal)
+
+	return sal, errors.WithStack(err)
+}
+
+// GetServiceAccountByID search service account by id
+func (s *Store) GetServiceAccountByID(ctx context.Context, id bson.ObjectId) (*model.ServiceAccount, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetServiceAccountByID")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	sa := &model.ServiceAccount{}
+
+	err := m.DB("").C(serviceAccountCollection).FindId(id).One(sa)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return sa, errors.WithStack(err)
+}
+
+// CreateServiceAccount insert new service account
+func (s *Store) CreateServiceAccount(ctx context.Context, sa *model.ServiceAccount) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateServiceAccount")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(serviceAccountCollection).Insert(sa)
+
+	if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// UpdateServiceAccount replace stored service account by id
+//
+// Credentials get new permission immediately, because they are not cached in tokens
+func (s *Store) UpdateServiceAccount(ctx context.Context, sa *model.ServiceAccount) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:UpdateServiceAccount")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(serviceAccountCollection).UpdateId(sa.ID, sa)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	} else if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// DeleteServiceAccount remove service account with all it's credentials
+func (s *Store) DeleteServiceAccount(ctx context.Context, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:DeleteServiceAccount")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(serviceAccountCollection).RemoveId(id)
+
+	if err == mgo.ErrNotFound {
+		return errors.WithStack(errs.ModelNotFound)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = m.DB("").C(accessTokenCollection).RemoveAll(bson.M{"userId": id, "service": true})
+
+	return errors.WithStack(err)
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const sessionCollection = "session"
+
+func (s *Store) initSession() error {
+	err := s.mongo.DB("").C(sessionCollection).EnsureIndex(mgo.Index{
+		Key: []string{"userId"},
+	})
+
+	if err == nil {
+		err = s.mongo.DB("").C(sessionCollection).EnsureIndex(mgo.Index{
+			Key:         []string{"expiresAt"},
+			ExpireAfter: time.Second,
+		})
+	}
+
+	return errors.WithStack(err)
+}
+
+// CreateSession insert new session
+func (s *Store) CreateSession(ctx context.Context, ss *model.Session) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateSession")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	return errors.WithStack(m.DB("").C(sessionCollection).Insert(ss))
+}
+
+// GetSessionByID search session by id
+func (s *Store) GetSessionByID(ctx context.Context, id bson.ObjectId) (*model.Session, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetSessionByID")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	ss := &model.Session{}
+
+	err := m.DB("").C(sessionCollection).FindId(id).One(ss)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return ss, errors.WithStack(err)
+}
+
+// GetSessionsByUser return all sessions of user, recently seen first
+func (s *Store) GetSessionsByUser(ctx context.Context, userID bson.ObjectId) ([]*model.Session, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetSessionsByUser")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	sl := []*model.Session{}
+
+	err := m.DB("").C(sessionCollection).Find(bson.M{"userId": userID}).Sort("-lastSeenAt").All(&sl)
+
+	return sl, errors.WithStack(err)
+}
+
+// TouchSession save last seen time of session and prolong it
+func (s *Store) TouchSession(ctx context.Context, id bson.ObjectId, lastSeenAt, expiresAt time.Time) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:TouchSession")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(sessionCollection).UpdateId(id, bson.M{"$set": bson.M{
+		"lastSeenAt": lastSeenAt,
+		"expiresAt":  expiresAt,
+	}})
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// DeleteSession remove session of user with it's refresh tokens
+func (s *Store) DeleteSession(ctx context.Context, userID, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:DeleteSession")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(sessionCollection).Remove(bson.M{"_id": id, "userId": userID})
+
+	if err == mgo.ErrNotFound {
+		return errors.WithStack(errs.ModelNotFound)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = m.DB("").C(refreshTokenCollection).RemoveAll(bson.M{"family": id})
+
+	return errors.WithStack(err)
+}
+
+// DeleteUserSessions remove all sessions of user with their refresh tokens
+func (s *Store) DeleteUserSessions(ctx context.Context, userID bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:DeleteUserSessions")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	return removeUserSessions(m, userID, "")
+}
+
+// removeUserSessions remove sessions of user except kept one
+// and all refresh tokens of user
+func removeUserSessions(m *mgo.Session, userID, keepSession bson.ObjectId) error {
+	query := bson.M{"userId": userID}
+	if keepSession != "" {
+		query["_id"] = bson.M{"$ne": keepSession}
+	}
+	if _, err := m.DB("").C(sessionCollection).RemoveAll(query); err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err := m.DB("").C(refreshTokenCollection).RemoveAll(bson.M{"userId": userID})
+
+	return errors.WithStack(err)
+}
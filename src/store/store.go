@@ -28,9 +28,71 @@ func New(cfg *config.Config) (*Store, error) {
 		return nil, errors.WithStack(err)
 	}
 
+	if err := s.initToken(); err != nil {
+
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.initAccessToken(); err != nil {
+
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.initSession(); err != nil {
+
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.initLoginThrottle(); err != nil {
+
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.initRole(); err != nil {
+
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.initGroup(); err != nil {
+
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.initAudit(); err != nil {
+
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.initServiceAccount(); err != nil {
+
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.initProject(); err != nil {
+
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.initMessage(); err != nil {
+
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.initTranslation(); err != nil {
+
+		return nil, errors.WithStack(err)
+	}
+
 	return s, nil
 }
 
+// isCommandError return true if err is an error of mongo command with code
+func isCommandError(err error, code int) bool {
+	qe, ok := err.(*mgo.QueryError)
+
+	return ok && qe.Code == code
+}
+
 // Close mongo session
 func (s *Store) Close() {
 	s.mongo.Close()
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	refreshTokenCollection = "refreshToken"
+	revokedTokenCollection = "revokedToken"
+)
+
+func (s *Store) initToken() error {
+	err := s.mongo.DB("").C(refreshTokenCollection).EnsureIndex(mgo.Index{
+		Key:    []string{"hash"},
+		Unique: true,
+	})
+
+	if err == nil {
+		err = s.mongo.DB("").C(refreshTokenCollection).EnsureIndex(mgo.Index{
+			Key: []string{"family"},
+		})
+	}
+
+	if err == nil {
+		err = s.mongo.DB("").C(refreshTokenCollection).EnsureIndex(mgo.Index{
+			Key:         []string{"expiresAt"},
+			ExpireAfter: time.Second,
+		})
+	}
+
+	if err == nil {
+		err = s.mongo.DB("").C(revokedTokenCollection).EnsureIndex(mgo.Index{
+			Key:         []string{"expiresAt"},
+			ExpireAfter: time.Second,
+		})
+	}
+
+	return errors.WithStack(err)
+}
+
+// CreateRefreshToken insert new refresh token
+func (s *Store) CreateRefreshToken(ctx context.Context, rt *model.RefreshToken) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateRefreshToken")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	return errors.WithStack(m.DB("").C(refreshTokenCollection).Insert(rt))
+}
+
+// GetRefreshTokenByHash search refresh token by hash
+func (s *Store) GetRefreshTokenByHash(ctx context.Context, hash []byte) (*model.RefreshToken, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetRefreshTokenByHash")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	rt := &model.RefreshToken{}
+
+	err := m.DB("").C(refreshTokenCollection).Find(bson.M{"hash": hash}).One(rt)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return rt, errors.WithStack(err)
+}
+
+// UseRefreshToken mark refresh token as used
+//
+// Return errs.ModelNotFound if token already used
+func (s *Store) UseRefreshToken(ctx context.Context, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:UseRefreshToken")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(refreshTokenCollection).Update(
+		bson.M{"_id": id, "usedAt": nil},
+		bson.M{"$set": bson.M{"usedAt": time.Now()}},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// DeleteRefreshTokenFamily remove all refresh tokens of family
+func (s *Store) DeleteRefreshTokenFamily(ctx context.Context, family bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:DeleteRefreshTokenFamily")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	_, err := m.DB("").C(refreshTokenCollection).RemoveAll(bson.M{"family": family})
+
+	return errors.WithStack(err)
+}
+
+// RevokeToken save jti of access token as revoked until it expires
+func (s *Store) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:RevokeToken")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	_, err := m.DB("").C(revokedTokenCollection).UpsertId(jti, bson.M{"$set": bson.M{
+		"expiresAt": expiresAt,
+	}})
+
+	return errors.WithStack(err)
+}
+
+// IsTokenRevoked check if jti of access token was revoked
+func (s *Store) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:IsTokenRevoked")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	n, err := m.DB("").C(revokedTokenCollection).FindId(jti).Count()
+
+	return n > 0, errors.WithStack(err)
+}
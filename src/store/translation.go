@@ -0,0 +1,282 @@
+package store
+
+import (
+	"context"
+
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	translationCollection           = "translation"
+	translationRevisionCollection   = "translationRevision"
+	translationTransitionCollection = "translationTransition"
+)
+
+func (s *Store) initTranslation() error {
+	err := s.mongo.DB("").C(translationCollection).EnsureIndex(mgo.Index{
+		Key:    []string{"messageId", "language"},
+		Unique: true,
+	})
+
+	if err == nil {
+		err = s.mongo.DB("").C(translationCollection).EnsureIndex(mgo.Index{
+			Key: []string{"projectId", "language"},
+		})
+	}
+
+	if err == nil {
+		err = s.mongo.DB("").C(translationRevisionCollection).EnsureIndex(mgo.Index{
+			Key:    []string{"messageId", "language", "number"},
+			Unique: true,
+		})
+	}
+
+	if err == nil {
+		err = s.mongo.DB("").C(translationTransitionCollection).EnsureIndex(mgo.Index{
+			Key: []string{"messageId", "language"},
+		})
+	}
+
+	if err == nil {
+		// Translations approved before approved revision was kept
+		err = s.migrate("translation.approvedRevision", func(db *mgo.Database) error {
+			c := db.C(translationCollection)
+			it := c.Find(bson.M{"state": model.Approved}).Iter()
+			t := &model.Translation{}
+			for it.Next(t) {
+				err := c.UpdateId(t.ID, bson.M{"$set": bson.M{"approvedRevision": t.Revision, "approvedText": t.Text}})
+				if err != nil {
+					it.Close()
+
+					return errors.WithStack(err)
+				}
+			}
+
+			return errors.WithStack(it.Close())
+		})
+	}
+
+	return errors.WithStack(err)
+}
+
+// GetTranslation search translation of message on language
+func (s *Store) GetTranslation(ctx context.Context, messageID bson.ObjectId, language string) (*model.Translation, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetTranslation")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	t := &model.Translation{}
+
+	err := m.DB("").C(translationCollection).Find(bson.M{"messageId": messageID, "language": language}).One(t)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return t, errors.WithStack(err)
+}
+
+// SaveTranslation insert revision and then insert translation or replace
+// existing one of same message and language with it
+//
+// ID and CreatedAt of existing translation are kept and reason of
+// rejection is removed. Revision with existing number is a concurrent
+// change and return errs.ModelExists
+func (s *Store) SaveTranslation(ctx context.Context, t *model.Translation, rev *model.TranslationRevision) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:SaveTranslation")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(translationRevisionCollection).Insert(rev)
+
+	if mgo.IsDup(err) {
+		return errors.WithStack(errs.ModelExists)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = m.DB("").C(translationCollection).Upsert(
+		bson.M{"messageId": t.MessageID, "language": t.Language},
+		bson.M{
+			"$set": bson.M{
+				"projectId":     t.ProjectID,
+				"text":          t.Text,
+				"revision":      t.Revision,
+				"state":         t.State,
+				"authorId":      t.AuthorID,
+				"authorService": t.AuthorService,
+				"updatedAt":     t.UpdatedAt,
+			},
+			"$unset":       bson.M{"reason": ""},
+			"$setOnInsert": bson.M{"_id": t.ID, "createdAt": t.CreatedAt},
+		},
+	)
+
+	return errors.WithStack(err)
+}
+
+// GetTranslationRevisions return revisions of translation from newest to oldest
+func (s *Store) GetTranslationRevisions(ctx context.Context, messageID bson.ObjectId, language string) ([]*model.TranslationRevision, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetTranslationRevisions")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	rl := []*model.TranslationRevision{}
+
+	err := m.DB("").C(translationRevisionCollection).
+		Find(bson.M{"messageId": messageID, "language": language}).
+		Sort("-number").
+		All(&rl)
+
+	return rl, errors.WithStack(err)
+}
+
+// GetTranslationRevision search revision of translation by number
+func (s *Store) GetTranslationRevision(ctx context.Context, messageID bson.ObjectId, language string, number int) (*model.TranslationRevision, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetTranslationRevision")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	rev := &model.TranslationRevision{}
+
+	err := m.DB("").C(translationRevisionCollection).
+		Find(bson.M{"messageId": messageID, "language": language, "number": number}).
+		One(rev)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return rev, errors.WithStack(err)
+}
+
+// GetTranslations return translations of messages on language,
+// only ones with approved revision if approvedOnly
+func (s *Store) GetTranslations(ctx context.Context, messageIDs []bson.ObjectId, language string, approvedOnly bool) ([]*model.Translation, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetTranslations")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	filter := bson.M{"messageId": bson.M{"$in": messageIDs}, "language": language}
+	if approvedOnly {
+		filter["approvedRevision"] = bson.M{"$gt": 0}
+	}
+
+	tl := []*model.Translation{}
+
+	err := m.DB("").C(translationCollection).Find(filter).All(&tl)
+
+	return tl, errors.WithStack(err)
+}
+
+// ChangeTranslationState set state and reason of translation from transition
+// and record transition
+//
+// State is changed only if translation has same state and revision as t,
+// otherwise it is a concurrent change and errs.ModelNotFound is returned.
+// Approved revision is kept and removed on rejection of it
+func (s *Store) ChangeTranslationState(ctx context.Context, t *model.Translation, tr *model.TranslationTransition) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:ChangeTranslationState")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	filter := bson.M{"_id": t.ID, "revision": t.Revision, "state": t.State}
+	if t.State == "" {
+		filter["state"] = nil
+	}
+	set := bson.M{"state": tr.To}
+	unset := bson.M{}
+	if tr.Reason != "" {
+		set["reason"] = tr.Reason
+	} else {
+		unset["reason"] = ""
+	}
+	if tr.To == model.Approved {
+		set["approvedRevision"] = t.Revision
+		set["approvedText"] = t.Text
+	} else if t.State == model.Approved {
+		unset["approvedRevision"] = ""
+		unset["approvedText"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	err := m.DB("").C(translationCollection).Update(filter, update)
+
+	if err == mgo.ErrNotFound {
+		return errors.WithStack(errs.ModelNotFound)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = m.DB("").C(translationTransitionCollection).Insert(tr)
+
+	return errors.WithStack(err)
+}
+
+// CreateTranslationTransition record transition of translation state
+func (s *Store) CreateTranslationTransition(ctx context.Context, tr *model.TranslationTransition) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateTranslationTransition")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(translationTransitionCollection).Insert(tr)
+
+	return errors.WithStack(err)
+}
+
+// GetTranslationTransitions return transitions of translation state from newest to oldest
+func (s *Store) GetTranslationTransitions(ctx context.Context, messageID bson.ObjectId, language string) ([]*model.TranslationTransition, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetTranslationTransitions")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	tl := []*model.TranslationTransition{}
+
+	err := m.DB("").C(translationTransitionCollection).
+		Find(bson.M{"messageId": messageID, "language": language}).
+		Sort("-_id").
+		All(&tl)
+
+	return tl, errors.WithStack(err)
+}
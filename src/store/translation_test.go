@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestApprovedRevision(t *testing.T) {
+	cfg := testConfig(t)
+	s, err := New(cfg)
+	require.NoError(t, err)
+
+	defer s.Close()
+
+	defer s.mongo.DB("").DropDatabase()
+
+	ctx := context.Background()
+	msgID := bson.NewObjectId()
+	save := func(text string) {
+		tr, err := s.GetTranslation(ctx, msgID, "de")
+		if err != nil {
+			tr = &model.Translation{ID: bson.NewObjectId(), MessageID: msgID, Language: "de", CreatedAt: time.Now()}
+		}
+		tr.Text = text
+		tr.Revision++
+		tr.State = model.Draft
+		rev := &model.TranslationRevision{ID: bson.NewObjectId(), MessageID: msgID, Language: "de", Number: tr.Revision, Text: text}
+		require.NoError(t, s.SaveTranslation(ctx, tr, rev))
+	}
+	transit := func(to model.TranslationState) {
+		tr, err := s.GetTranslation(ctx, msgID, "de")
+		require.NoError(t, err)
+		reason := ""
+		if to == model.Rejected {
+			reason = "wrong"
+		}
+		err = s.ChangeTranslationState(ctx, tr, &model.TranslationTransition{ID: bson.NewObjectId(), From: tr.State, To: to, Reason: reason})
+		require.NoError(t, err)
+	}
+	approved := func() []*model.Translation {
+		tl, err := s.GetTranslations(ctx, []bson.ObjectId{msgID}, "de", true)
+		require.NoError(t, err)
+
+		return tl
+	}
+
+	save("Hallo")
+	assert.Empty(t, approved(), "draft is not approved")
+
+	transit(model.NeedsReview)
+	transit(model.Approved)
+	save("Hallo Welt")
+	tl := approved()
+	require.Len(t, tl, 1, "edited translation keep approved revision")
+	assert.Equal(t, model.Draft, tl[0].State)
+	assert.Equal(t, 1, tl[0].ApprovedRevision)
+	assert.Equal(t, "Hallo", tl[0].ApprovedText)
+
+	transit(model.NeedsReview)
+	transit(model.Rejected)
+	tl = approved()
+	require.Len(t, tl, 1, "rejection of newer revision keep approved one")
+	assert.Equal(t, "Hallo", tl[0].ApprovedText)
+
+	transit(model.NeedsReview)
+	transit(model.Approved)
+	tl = approved()
+	require.Len(t, tl, 1)
+	assert.Equal(t, 2, tl[0].ApprovedRevision)
+	assert.Equal(t, "Hallo Welt", tl[0].ApprovedText)
+
+	transit(model.Rejected)
+	assert.Empty(t, approved(), "rejection of approved revision remove it")
+}
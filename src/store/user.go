@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/l10n-center/api/src/errs"
 	"github.com/l10n-center/api/src/model"
@@ -14,6 +15,12 @@ import (
 
 const userCollection = "user"
 
+// Codes of mongo command errors
+const (
+	codeIndexNotFound   = 27
+	codeNamespaceExists = 48
+)
+
 func (s *Store) initUser() error {
 	err := s.mongo.DB("").C(userCollection).Create(&mgo.CollectionInfo{
 		Validator: bson.M{"username": bson.M{
@@ -22,17 +29,74 @@ func (s *Store) initUser() error {
 		}},
 	})
 
+	if isCommandError(err, codeNamespaceExists) {
+		err = nil
+	}
+
+	if err == nil {
+		// Email is unique only for not deleted users, so it can be used
+		// by new user before deleted one is purged
+		err = s.mongo.DB("").C(userCollection).DropIndex("email")
+		if isCommandError(err, codeIndexNotFound) {
+			err = nil
+		}
+	}
+
 	if err == nil {
 		err = s.mongo.DB("").C(userCollection).EnsureIndex(mgo.Index{
-			Key:    []string{"email"},
+			Key:    []string{"email", "deletedAt"},
 			Unique: true,
 		})
 	}
 
+	if err == nil {
+		err = s.mongo.DB("").C(userCollection).EnsureIndex(mgo.Index{
+			Key:    []string{"resetToken"},
+			Sparse: true,
+		})
+	}
+
+	if err == nil {
+		err = s.mongo.DB("").C(userCollection).EnsureIndex(mgo.Index{
+			Key:    []string{"emailToken"},
+			Sparse: true,
+		})
+	}
+
+	if err == nil {
+		err = s.mongo.DB("").C(userCollection).EnsureIndex(mgo.Index{
+			Key:    []string{"deletedAt"},
+			Sparse: true,
+		})
+	}
+
+	if err == nil {
+		err = s.mongo.DB("").C(userCollection).EnsureIndex(mgo.Index{
+			Key: []string{"roles"},
+		})
+	}
+
+	if err == nil {
+		// Users created before email verification never had pending email,
+		// treat them as verified to not restrict them by unverified policy
+		err = s.migrate("user.emailVerifiedAt", func(db *mgo.Database) error {
+			_, err := db.C(userCollection).UpdateAll(
+				bson.M{
+					"emailVerifiedAt": bson.M{"$exists": false},
+					"pendingEmail":    bson.M{"$exists": false},
+					"inviteNonce":     bson.M{"$exists": false},
+				},
+				bson.M{"$set": bson.M{"emailVerifiedAt": time.Now()}},
+			)
+
+			return errors.WithStack(err)
+		})
+	}
+
 	return errors.WithStack(err)
 }
 
-// GetUserCount return count of users
+// GetUserCount return count of not deleted users
 func (s *Store) GetUserCount(ctx context.Context) (int, error) {
 	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetUserCount")
 
@@ -42,12 +106,12 @@ func (s *Store) GetUserCount(ctx context.Context) (int, error) {
 
 	defer m.Close()
 
-	n, err := m.DB("").C(userCollection).Count()
+	n, err := m.DB("").C(userCollection).Find(bson.M{"deletedAt": nil}).Count()
 
 	return n, errors.WithStack(err)
 }
 
-// GetUserByID search user collection by id
+// GetUserByID search not deleted user by id
 func (s *Store) GetUserByID(ctx context.Context, id bson.ObjectId) (*model.User, error) {
 	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetUserByID")
 
@@ -59,7 +123,7 @@ func (s *Store) GetUserByID(ctx context.Context, id bson.ObjectId) (*model.User,
 
 	u := &model.User{}
 
-	err := m.DB("").C(userCollection).FindId(id).One(u)
+	err := m.DB("").C(userCollection).Find(bson.M{"_id": id, "deletedAt": nil}).One(u)
 
 	if err == mgo.ErrNotFound {
 		err = errs.ModelNotFound
@@ -68,7 +132,7 @@ func (s *Store) GetUserByID(ctx context.Context, id bson.ObjectId) (*model.User,
 	return u, errors.WithStack(err)
 }
 
-// GetUserByEmail search user by email
+// GetUserByEmail search not deleted user by email
 func (s *Store) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetUserByEmail")
 
@@ -80,7 +144,7 @@ func (s *Store) GetUserByEmail(ctx context.Context, email string) (*model.User,
 
 	u := &model.User{}
 
-	err := m.DB("").C(userCollection).Find(bson.M{"email": email}).One(u)
+	err := m.DB("").C(userCollection).Find(bson.M{"email": email, "deletedAt": nil}).One(u)
 
 	if err == mgo.ErrNotFound {
 		err = errs.ModelNotFound
@@ -89,6 +153,21 @@ func (s *Store) GetUserByEmail(ctx context.Context, email string) (*model.User,
 	return u, errors.WithStack(err)
 }
 
+// HasDeletedUser return true if deleted and not purged user has email
+func (s *Store) HasDeletedUser(ctx context.Context, email string) (bool, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:HasDeletedUser")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	n, err := m.DB("").C(userCollection).Find(bson.M{"email": email, "deletedAt": bson.M{"$ne": nil}}).Count()
+
+	return n > 0, errors.WithStack(err)
+}
+
 // CreateUser insert new user
 func (s *Store) CreateUser(ctx context.Context, u *model.User) error {
 	sp, _ := opentracing.StartSpanFromContext(ctx, "db:CreateUser")
@@ -99,5 +178,796 @@ func (s *Store) CreateUser(ctx context.Context, u *model.User) error {
 
 	defer m.Close()
 
-	return errors.WithStack(m.DB("").C(userCollection).Insert(u))
+	err := m.DB("").C(userCollection).Insert(u)
+
+	if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// SetUserResetToken save hash of password reset token and it's expiration time
+func (s *Store) SetUserResetToken(ctx context.Context, id bson.ObjectId, token []byte, until time.Time) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:SetUserResetToken")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": id, "deletedAt": nil},
+		bson.M{"$set": bson.M{
+			"resetToken": token,
+			"resetUntil": until,
+			"updatedAt":  time.Now(),
+		}},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// ResetUserPassword replace passhash of user with not expired reset token,
+// remove token, so it can be used only once, and revoke all issued auth tokens
+// with sessions and refresh tokens of user
+func (s *Store) ResetUserPassword(ctx context.Context, token []byte, passhash []byte) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:ResetUserPassword")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	u := &model.User{}
+
+	_, err := m.DB("").C(userCollection).Find(bson.M{
+		"resetToken": token,
+		"resetUntil": bson.M{"$gt": time.Now()},
+		"deletedAt":  nil,
+	}).Apply(mgo.Change{
+		Update: bson.M{
+			"$set": bson.M{
+				"passhash":  passhash,
+				"updatedAt": time.Now(),
+			},
+			"$unset": bson.M{
+				"resetToken": "",
+				"resetUntil": "",
+			},
+			"$inc": bson.M{"tokenVersion": 1},
+		},
+	}, u)
+	if err == mgo.ErrNotFound {
+		return errors.WithStack(errs.ModelNotFound)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return removeUserSessions(m, u.ID, "")
+}
+
+// GetUserByResetToken search user by not expired password reset token
+func (s *Store) GetUserByResetToken(ctx context.Context, token []byte) (*model.User, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetUserByResetToken")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	u := &model.User{}
+
+	err := m.DB("").C(userCollection).Find(bson.M{
+		"resetToken": token,
+		"resetUntil": bson.M{"$gt": time.Now()},
+		"deletedAt":  nil,
+	}).One(u)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return u, errors.WithStack(err)
+}
+
+// UpdateUserPasshash replace password hash of user without revoke of tokens
+func (s *Store) UpdateUserPasshash(ctx context.Context, id bson.ObjectId, passhash []byte) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:UpdateUserPasshash")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).UpdateId(id, bson.M{"$set": bson.M{"passhash": passhash}})
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// ChangeUserPasshash replace password hash of user, revoke all issued
+// tokens and remove sessions except kept one with all refresh tokens
+//
+// Return new token version of user to issue tokens of kept session
+func (s *Store) ChangeUserPasshash(ctx context.Context, id bson.ObjectId, passhash []byte, keepSession bson.ObjectId) (int, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:ChangeUserPasshash")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	u := &model.User{}
+
+	_, err := m.DB("").C(userCollection).Find(bson.M{"_id": id, "deletedAt": nil}).Apply(mgo.Change{
+		Update: bson.M{
+			"$set": bson.M{"passhash": passhash, "updatedAt": time.Now()},
+			"$inc": bson.M{"tokenVersion": 1},
+		},
+		ReturnNew: true,
+	}, u)
+	if err == mgo.ErrNotFound {
+		return 0, errors.WithStack(errs.ModelNotFound)
+	} else if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return u.TokenVersion, removeUserSessions(m, id, keepSession)
+}
+
+// SetUserEmailChange save pending email of user with hash of token
+// to confirm it and it's expiration time
+func (s *Store) SetUserEmailChange(ctx context.Context, id bson.ObjectId, email string, token []byte, until time.Time) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:SetUserEmailChange")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": id, "deletedAt": nil},
+		bson.M{"$set": bson.M{
+			"pendingEmail": email,
+			"emailToken":   token,
+			"emailUntil":   until,
+		}},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// ConfirmUserEmail replace email of user with pending one and mark it
+// as verified if token is not expired and remove token
+//
+// Return errs.ModelExists if pending email is already used
+func (s *Store) ConfirmUserEmail(ctx context.Context, id bson.ObjectId, token []byte) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:ConfirmUserEmail")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	c := m.DB("").C(userCollection)
+	query := bson.M{
+		"_id":        id,
+		"emailToken": token,
+		"emailUntil": bson.M{"$gt": time.Now()},
+		"deletedAt":  nil,
+	}
+
+	u := &model.User{}
+
+	err := c.Find(query).One(u)
+	if err == nil {
+		now := time.Now()
+		set := bson.M{"emailVerifiedAt": now, "updatedAt": now}
+		if u.PendingEmail != "" {
+			set["email"] = u.PendingEmail
+		}
+		err = c.Update(query, bson.M{
+			"$set": set,
+			"$unset": bson.M{
+				"pendingEmail": "",
+				"emailToken":   "",
+				"emailUntil":   "",
+			},
+		})
+	}
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	} else if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// VerifyUserEmail mark current email of user as verified without token
+//
+// Pending change to other email is kept
+func (s *Store) VerifyUserEmail(ctx context.Context, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:VerifyUserEmail")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	c := m.DB("").C(userCollection)
+	query := bson.M{"_id": id, "deletedAt": nil}
+
+	u := &model.User{}
+
+	err := c.Find(query).One(u)
+	if err == nil {
+		now := time.Now()
+		update := bson.M{"$set": bson.M{"emailVerifiedAt": now, "updatedAt": now}}
+		if u.PendingEmail == u.Email {
+			update["$unset"] = bson.M{
+				"pendingEmail": "",
+				"emailToken":   "",
+				"emailUntil":   "",
+			}
+		}
+		err = c.Update(query, update)
+	}
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// GetUserByEmailToken search user by not expired email verification token
+func (s *Store) GetUserByEmailToken(ctx context.Context, token []byte) (*model.User, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetUserByEmailToken")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	u := &model.User{}
+
+	err := m.DB("").C(userCollection).Find(bson.M{
+		"emailToken": token,
+		"emailUntil": bson.M{"$gt": time.Now()},
+		"deletedAt":  nil,
+	}).One(u)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return u, errors.WithStack(err)
+}
+
+// SetUserPreferences replace interface preferences of user
+func (s *Store) SetUserPreferences(ctx context.Context, id bson.ObjectId, p model.Preferences) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:SetUserPreferences")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": id, "deletedAt": nil},
+		bson.M{"$set": bson.M{"preferences": p, "updatedAt": time.Now()}},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// GetUsers return all not deleted users ordered by email
+func (s *Store) GetUsers(ctx context.Context) ([]*model.User, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetUsers")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	ul := []*model.User{}
+
+	err := m.DB("").C(userCollection).Find(bson.M{"deletedAt": nil}).Sort("email").All(&ul)
+
+	return ul, errors.WithStack(err)
+}
+
+// UpdateUser set changed fields of not deleted user by id and revoke
+// all issued tokens of user if any field is changed
+//
+// Only changed fields are written, so concurrent updates of other fields,
+// such as tokenVersion or TOTP state, are not lost.
+func (s *Store) UpdateUser(ctx context.Context, id bson.ObjectId, ch *model.UserChange) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:UpdateUser")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	set := bson.M{"updatedAt": time.Now()}
+	update := bson.M{"$set": set}
+	if ch.Email != nil {
+		set["email"] = *ch.Email
+		update["$unset"] = bson.M{"emailVerifiedAt": ""}
+	}
+	if ch.Passhash != nil {
+		set["passhash"] = ch.Passhash
+	}
+	if ch.IsAdmin != nil {
+		set["isAdmin"] = *ch.IsAdmin
+	}
+	if ch.Permission != nil {
+		set["permission"] = *ch.Permission
+	}
+	if ch.Roles != nil {
+		set["roles"] = *ch.Roles
+	}
+	if len(set) > 1 {
+		// Issued tokens contain old claims
+		update["$inc"] = bson.M{"tokenVersion": 1}
+	}
+
+	err := m.DB("").C(userCollection).Update(bson.M{"_id": id, "deletedAt": nil}, update)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	} else if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// DeleteUser mark user as deleted, revoke all it's tokens and remove sessions,
+// so restored user has to login again
+func (s *Store) DeleteUser(ctx context.Context, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:DeleteUser")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	now := time.Now()
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": id, "deletedAt": nil},
+		bson.M{
+			"$set": bson.M{"deletedAt": now, "updatedAt": now},
+			"$inc": bson.M{"tokenVersion": 1},
+		},
+	)
+
+	if err == mgo.ErrNotFound {
+		return errors.WithStack(errs.ModelNotFound)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return removeUserSessions(m, id, "")
+}
+
+// RestoreUser remove deleted mark from user
+//
+// Return errs.ModelExists if email of user is used by another user
+func (s *Store) RestoreUser(ctx context.Context, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:RestoreUser")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}},
+		bson.M{
+			"$set":   bson.M{"updatedAt": time.Now()},
+			"$unset": bson.M{"deletedAt": ""},
+		},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	} else if mgo.IsDup(err) {
+		err = errs.ModelExists
+	}
+
+	return errors.WithStack(err)
+}
+
+// SetUserLanguages replace binded languages of user and revoke
+// all it's tokens, which contain old languages
+func (s *Store) SetUserLanguages(ctx context.Context, id bson.ObjectId, languages []string) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:SetUserLanguages")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": id, "deletedAt": nil},
+		bson.M{
+			"$set": bson.M{"languages": languages, "updatedAt": time.Now()},
+			"$inc": bson.M{"tokenVersion": 1},
+		},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// GetDeletedUsers return deleted users ordered by deletion time
+func (s *Store) GetDeletedUsers(ctx context.Context) ([]*model.User, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetDeletedUsers")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	ul := []*model.User{}
+
+	err := m.DB("").C(userCollection).Find(bson.M{"deletedAt": bson.M{"$ne": nil}}).Sort("deletedAt").All(&ul)
+
+	return ul, errors.WithStack(err)
+}
+
+// PurgeDeletedUsers permanently remove users deleted before time
+// with their personal access tokens, sessions and refresh tokens
+func (s *Store) PurgeDeletedUsers(ctx context.Context, before time.Time) (int, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:PurgeDeletedUsers")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	ids := []bson.ObjectId{}
+	ul := []*model.User{}
+
+	err := m.DB("").C(userCollection).Find(bson.M{"deletedAt": bson.M{"$lt": before}}).Select(bson.M{"_id": 1}).All(&ul)
+	if err != nil || len(ul) == 0 {
+		return 0, errors.WithStack(err)
+	}
+	for _, u := range ul {
+		ids = append(ids, u.ID)
+	}
+
+	byUser := bson.M{"userId": bson.M{"$in": ids}}
+	if _, err = m.DB("").C(accessTokenCollection).RemoveAll(byUser); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if _, err = m.DB("").C(sessionCollection).RemoveAll(byUser); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if _, err = m.DB("").C(refreshTokenCollection).RemoveAll(byUser); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	info, err := m.DB("").C(userCollection).RemoveAll(bson.M{
+		"_id":       bson.M{"$in": ids},
+		"deletedAt": bson.M{"$lt": before},
+	})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return info.Removed, nil
+}
+
+// GetInvitedUsers return users with not accepted invitation
+func (s *Store) GetInvitedUsers(ctx context.Context) ([]*model.User, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:GetInvitedUsers")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	ul := []*model.User{}
+
+	err := m.DB("").C(userCollection).Find(bson.M{
+		"inviteNonce": bson.M{"$exists": true},
+		"deletedAt":   nil,
+	}).Sort("invitedAt").All(&ul)
+
+	return ul, errors.WithStack(err)
+}
+
+// SetUserInvite replace invitation nonce of invited user,
+// so previously sent invitation is no more valid
+func (s *Store) SetUserInvite(ctx context.Context, id bson.ObjectId, nonce string, at time.Time) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:SetUserInvite")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": id, "inviteNonce": bson.M{"$exists": true}},
+		bson.M{"$set": bson.M{
+			"inviteNonce": nonce,
+			"invitedAt":   at,
+			"updatedAt":   time.Now(),
+		}},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// AcceptUserInvite set passhash of invited user if nonce match, mark
+// email as verified and remove invitation, so it can be accepted only once
+func (s *Store) AcceptUserInvite(ctx context.Context, id bson.ObjectId, nonce string, passhash []byte) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:AcceptUserInvite")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": id, "inviteNonce": nonce, "deletedAt": nil},
+		bson.M{
+			"$set": bson.M{
+				"passhash":        passhash,
+				"emailVerifiedAt": time.Now(),
+				"updatedAt":       time.Now(),
+			},
+			"$unset": bson.M{
+				"inviteNonce": "",
+				"invitedAt":   "",
+			},
+		},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// RemoveInvitedUser delete user with not accepted invitation
+func (s *Store) RemoveInvitedUser(ctx context.Context, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:RemoveInvitedUser")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Remove(bson.M{
+		"_id":         id,
+		"inviteNonce": bson.M{"$exists": true},
+	})
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// SetUserTOTPSecret set pending TOTP secret of user without enabled TOTP
+func (s *Store) SetUserTOTPSecret(ctx context.Context, id bson.ObjectId, secret string) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:SetUserTOTPSecret")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": id, "totpEnabledAt": nil, "deletedAt": nil},
+		bson.M{
+			"$set": bson.M{
+				"totpSecret": secret,
+				"updatedAt":  time.Now(),
+			},
+		},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// EnableUserTOTP enable pending TOTP secret and set recovery code hashes
+func (s *Store) EnableUserTOTP(ctx context.Context, id bson.ObjectId, step int64, codes [][]byte) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:EnableUserTOTP")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{
+			"_id":           id,
+			"totpEnabledAt": nil,
+			"totpSecret":    bson.M{"$exists": true},
+		},
+		bson.M{
+			"$set": bson.M{
+				"totpEnabledAt": time.Now(),
+				"totpStep":      step,
+				"recoveryCodes": codes,
+				"updatedAt":     time.Now(),
+			},
+		},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// DisableUserTOTP remove TOTP secret and recovery codes of user
+func (s *Store) DisableUserTOTP(ctx context.Context, id bson.ObjectId) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:DisableUserTOTP")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).UpdateId(
+		id,
+		bson.M{
+			"$set": bson.M{"updatedAt": time.Now()},
+			"$unset": bson.M{
+				"totpSecret":    "",
+				"totpEnabledAt": "",
+				"totpStep":      "",
+				"recoveryCodes": "",
+			},
+		},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// UseUserTOTPStep store time step of used TOTP code
+//
+// Return errs.ModelNotFound if same or later step was already used
+func (s *Store) UseUserTOTPStep(ctx context.Context, id bson.ObjectId, step int64) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:UseUserTOTPStep")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{
+			"_id": id,
+			"$or": []bson.M{
+				{"totpStep": bson.M{"$lt": step}},
+				{"totpStep": bson.M{"$exists": false}},
+			},
+		},
+		bson.M{"$set": bson.M{"totpStep": step}},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// UseUserRecoveryCode remove recovery code hash from user
+//
+// Return errs.ModelNotFound if user has no such code
+func (s *Store) UseUserRecoveryCode(ctx context.Context, id bson.ObjectId, code []byte) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:UseUserRecoveryCode")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": id, "recoveryCodes": code},
+		bson.M{"$pull": bson.M{"recoveryCodes": code}},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
+}
+
+// SetUserRecoveryCodes replace recovery code hashes of user with enabled TOTP
+func (s *Store) SetUserRecoveryCodes(ctx context.Context, id bson.ObjectId, codes [][]byte) error {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "db:SetUserRecoveryCodes")
+
+	defer sp.Finish()
+
+	m := s.mongo.Clone()
+
+	defer m.Close()
+
+	err := m.DB("").C(userCollection).Update(
+		bson.M{"_id": id, "totpEnabledAt": bson.M{"$ne": nil}},
+		bson.M{
+			"$set": bson.M{
+				"recoveryCodes": codes,
+				"updatedAt":     time.Now(),
+			},
+		},
+	)
+
+	if err == mgo.ErrNotFound {
+		err = errs.ModelNotFound
+	}
+
+	return errors.WithStack(err)
 }
@@ -0,0 +1,197 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/mail"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// sendInvite mail invitation token to user
+func sendInvite(ctx context.Context, mailer mail.Mailer, u *model.User, token string) error {
+	return errors.WithStack(mailer.Send(ctx, &mail.Message{
+		To:      u.Email,
+		Subject: "Invitation to l10n-center",
+		Body: fmt.Sprintf(
+			"You are invited to l10n-center.\n\nUse this token to set your password: %s\n\nIt expires in %s.",
+			token,
+			auth.InviteTokenTTL,
+		),
+	}))
+}
+
+// Invites list users with not accepted invitations
+func Invites(_ *config.Config, store Store) http.HandlerFunc {
+	// List users with not accepted invitations
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		ul, err := store.GetInvitedUsers(ctx)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, ul)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Invite create pending user and send invitation to it
+func Invite(_ *config.Config, keys *auth.Keyring, store Store, mailer mail.Mailer) http.HandlerFunc {
+	// Create pending user and send invitation to it
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Email      string           `json:"email" valid:"email,required"`
+			IsAdmin    bool             `json:"isAdmin"`
+			Permission model.Permission `json:"permission"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		u := &model.User{
+			ID:         bson.NewObjectId(),
+			Email:      rd.Email,
+			IsAdmin:    rd.IsAdmin,
+			Permission: rd.Permission & model.CanEverything,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+		token, err := auth.Invite(keys, u)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		err = store.CreateUser(ctx, u)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "email already used", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if err = sendInvite(ctx, mailer, u, token); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "user.invite", u.ID.Hex(), nil, u)
+		l.Sugar().Infof("user <%s> invited", u.Email)
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, u)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// ResendInvite generate new invitation token and send it again
+//
+// Previously sent invitation become invalid
+func ResendInvite(_ *config.Config, keys *auth.Keyring, store Store, mailer mail.Mailer) http.HandlerFunc {
+	// Generate new invitation token and send it again
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "invitation not found", http.StatusNotFound)
+			return
+		}
+		u, err := store.GetUserByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound || err == nil && u.InviteNonce == "" {
+			l.Debug("invitation not found", zap.Error(err))
+			http.Error(w, "invitation not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		token, err := auth.Invite(keys, u)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		err = store.SetUserInvite(ctx, u.ID, u.InviteNonce, *u.InvitedAt)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "invitation not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if err = sendInvite(ctx, mailer, u, token); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "user.invite.resend", u.ID.Hex(), nil, nil)
+		l.Sugar().Infof("invitation resent to <%s>", u.Email)
+		http.Error(w, "invitation sent", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// RevokeInvite delete pending user, so invitation can't be accepted
+func RevokeInvite(_ *config.Config, store Store) http.HandlerFunc {
+	// Delete pending user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "invitation not found", http.StatusNotFound)
+			return
+		}
+		err := store.RemoveInvitedUser(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "invitation not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "user.invite.revoke", id.Hex(), nil, nil)
+		l.Sugar().Infof("invitation of <%s> revoked", id.Hex())
+		http.Error(w, "invitation revoked", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
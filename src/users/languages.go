@@ -0,0 +1,95 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/pkg/errors"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+)
+
+// Languages binded to user
+func Languages(_ *config.Config, store Store) http.HandlerFunc {
+	// Languages binded to user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		u, err := store.GetUserByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		languages := u.Languages
+		if languages == nil {
+			languages = []string{}
+		}
+		render.JSON(w, r, languages)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// SetLanguages replace languages binded to user
+//
+// Languages are BCP 47 tags, which are stored in canonical case
+func SetLanguages(_ *config.Config, store Store) http.HandlerFunc {
+	// Replace languages binded to user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := []string{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		languages, err := model.NormalizeLanguages(rd)
+		if err != nil {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		err = store.SetUserLanguages(ctx, id, languages)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "user.languages", id.Hex(), nil, map[string][]string{"languages": languages})
+		l.Sugar().Infof("languages of user <%s> set to %v", id.Hex(), languages)
+		render.JSON(w, r, languages)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,48 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/pkg/errors"
+)
+
+// RetentionInterval between removals of deleted users
+const RetentionInterval = time.Hour
+
+// Purge permanently remove users deleted more than cfg.UserRetention days ago
+func Purge(ctx context.Context, cfg *config.Config, store Store) (int, error) {
+	if cfg.UserRetention <= 0 {
+		return 0, nil
+	}
+	before := time.Now().AddDate(0, 0, -cfg.UserRetention)
+	n, err := store.PurgeDeletedUsers(ctx, before)
+
+	return n, errors.WithStack(err)
+}
+
+// Retention run Purge every RetentionInterval until ctx is done
+func Retention(ctx context.Context, cfg *config.Config, store Store) {
+	l := tracing.Logger(ctx)
+	t := time.NewTicker(RetentionInterval)
+
+	defer t.Stop()
+
+	for {
+		n, err := Purge(ctx, cfg, store)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+		} else if n > 0 {
+			l.Sugar().Infof("%d deleted users removed", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package users_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/users"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurge(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	store := NewMockStore(mockCtrl)
+	store.EXPECT().
+		PurgeDeletedUsers(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, before time.Time) {
+			assert.WithinDuration(t, time.Now().AddDate(0, 0, -cfg.UserRetention), before, time.Minute)
+		}).
+		Return(2, nil)
+
+	n, err := users.Purge(context.Background(), cfg, store)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// Retention is disabled
+	cfg.UserRetention = 0
+	n, err = users.Purge(context.Background(), cfg, store)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
@@ -0,0 +1,488 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/mail"
+	"github.com/l10n-center/api/src/middleware"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Router return users section router
+//
+// All routes are available only for admins
+func Router(cfg *config.Config, keys *auth.Keyring, store Store, mailer mail.Mailer) func(chi.Router) {
+
+	return func(r chi.Router) {
+		r.Use(auth.WithClaims(cfg, keys, store, true, true))
+		r.Use(middleware.JSONOnly)
+
+		r.Get("/", List(cfg, store))
+		r.Get("/deleted", Deleted(cfg, store))
+		r.Post("/", Create(cfg, store, mailer))
+		r.Get("/:id", Get(cfg, store))
+		r.Patch("/:id", Update(cfg, store, mailer))
+		r.Delete("/:id", Delete(cfg, store))
+		r.Post("/:id/restore", Restore(cfg, store))
+		r.Get("/:id/languages", Languages(cfg, store))
+		r.Put("/:id/languages", SetLanguages(cfg, store))
+		r.Delete("/:id/2fa", ResetTOTP(cfg, store))
+		r.Post("/:id/unlock", Unlock(cfg, store))
+		r.Post("/:id/verify", VerifyEmail(cfg, store))
+		r.Post("/:id/verify/resend", ResendVerification(cfg, store, mailer))
+		r.Get("/:id/sessions", Sessions(cfg, store))
+		r.Delete("/:id/sessions/:sessionId", DeleteSession(cfg, store))
+		r.Get("/invites", Invites(cfg, store))
+		r.Post("/invite", Invite(cfg, keys, store, mailer))
+		r.Post("/:id/invite", ResendInvite(cfg, keys, store, mailer))
+		r.Delete("/:id/invite", RevokeInvite(cfg, store))
+	}
+}
+
+// userID extract user id from url params
+func userID(r *http.Request) (bson.ObjectId, bool) {
+	id := chi.URLParam(r, "id")
+	if !bson.IsObjectIdHex(id) {
+
+		return "", false
+	}
+
+	return bson.ObjectIdHex(id), true
+}
+
+// checkRoles return false if some of roles are not exist
+func checkRoles(ctx context.Context, store Store, names []string) (bool, error) {
+	if len(names) == 0 {
+		return true, nil
+	}
+	rl, err := store.GetRolesByNames(ctx, names)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	found := map[string]bool{}
+	for _, role := range rl {
+		found[role.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// List of not deleted users
+func List(_ *config.Config, store Store) http.HandlerFunc {
+	// List of not deleted users
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		ul, err := store.GetUsers(ctx)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, ul)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Deleted users, which will be removed after retention period
+func Deleted(_ *config.Config, store Store) http.HandlerFunc {
+	// List of deleted users
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		ul, err := store.GetDeletedUsers(ctx)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, ul)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Get user by id
+func Get(_ *config.Config, store Store) http.HandlerFunc {
+	// Get user by id
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		u, err := store.GetUserByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, u)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Create new user and send verification token to it's email
+func Create(cfg *config.Config, store Store, mailer mail.Mailer) http.HandlerFunc {
+	// Create new user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Email      string           `json:"email" valid:"email,required"`
+			Password   string           `json:"password" valid:"required"`
+			IsAdmin    bool             `json:"isAdmin"`
+			Permission model.Permission `json:"permission"`
+			Roles      []string         `json:"roles"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		ok, err := checkRoles(ctx, store, rd.Roles)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			l.Debug("unknown role")
+			http.Error(w, "validate: roles: unknown role", http.StatusBadRequest)
+			return
+		}
+		passhash, err := auth.HashPassword(cfg, rd.Email, rd.Password)
+		if auth.IsWeakPassword(err) {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			l.Error(err.Error())
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		u := &model.User{
+			ID:         bson.NewObjectId(),
+			Email:      rd.Email,
+			Passhash:   passhash,
+			IsAdmin:    rd.IsAdmin,
+			Permission: rd.Permission & model.CanEverything,
+			Roles:      rd.Roles,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+		err = store.CreateUser(ctx, u)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "email already used", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "user.create", u.ID.Hex(), nil, u)
+		if err = auth.SendVerification(ctx, store, mailer, u, u.Email); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+		}
+		l.Sugar().Infof("user created with email <%s>", u.Email)
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, u)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Update user fields present in request
+//
+// Changed email is not verified until user confirm it by sent token
+func Update(cfg *config.Config, store Store, mailer mail.Mailer) http.HandlerFunc {
+	// Update user fields present in request
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+
+		jd := json.NewDecoder(r.Body)
+
+		rd := &struct {
+			Email      *string           `json:"email" valid:"email"`
+			Password   *string           `json:"password"`
+			IsAdmin    *bool             `json:"isAdmin"`
+			Permission *model.Permission `json:"permission"`
+			Roles      *[]string         `json:"roles"`
+		}{}
+		if err := errors.WithMessage(jd.Decode(&rd), "unmarshal"); err != nil {
+			l.Debug(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ok, err := govalidator.ValidateStruct(rd); !ok {
+			l.Debug(err.Error())
+			http.Error(w, errors.WithMessage(err, "validate").Error(), http.StatusBadRequest)
+			return
+		}
+		u, err := store.GetUserByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		before := *u
+		ch := &model.UserChange{}
+		if rd.Email != nil && *rd.Email != u.Email {
+			u.Email = *rd.Email
+			u.EmailVerifiedAt = nil
+			ch.Email = &u.Email
+		}
+		if rd.Password != nil {
+			u.Passhash, err = auth.HashPassword(cfg, u.Email, *rd.Password)
+			ch.Passhash = u.Passhash
+			if auth.IsWeakPassword(err) {
+				l.Debug(err.Error())
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			} else if err != nil {
+				l.Error(err.Error())
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+		}
+		if rd.IsAdmin != nil {
+			u.IsAdmin = *rd.IsAdmin
+			ch.IsAdmin = &u.IsAdmin
+		}
+		if rd.Permission != nil {
+			u.Permission = *rd.Permission & model.CanEverything
+			ch.Permission = &u.Permission
+		}
+		if rd.Roles != nil {
+			ok, err = checkRoles(ctx, store, *rd.Roles)
+			if err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				l.Debug("unknown role")
+				http.Error(w, "validate: roles: unknown role", http.StatusBadRequest)
+				return
+			}
+			u.Roles = *rd.Roles
+			ch.Roles = &u.Roles
+		}
+		u.UpdatedAt = time.Now()
+		err = store.UpdateUser(ctx, u.ID, ch)
+		if errors.Cause(err) == errs.ModelExists {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "email already used", http.StatusConflict)
+			return
+		} else if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if rd.Password != nil {
+			// Sessions of user can't be continued with old password
+			if err = store.DeleteUserSessions(ctx, u.ID); err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+				http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+				return
+			}
+		}
+		auth.Audit(ctx, store, r, "user.update", u.ID.Hex(), &before, u)
+		if u.Email != before.Email {
+			if err = auth.SendVerification(ctx, store, mailer, u, u.Email); err != nil {
+				l.Error(err.Error(), errs.ZapStack(err))
+			}
+		}
+		l.Sugar().Infof("user <%s> updated", u.Email)
+		render.JSON(w, r, u)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Delete mark user as deleted
+func Delete(_ *config.Config, store Store) http.HandlerFunc {
+	// Mark user as deleted
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		if c, ok := auth.ClaimsFromContext(ctx); ok && c.UserID == id {
+			l.Debug("self deletion")
+			http.Error(w, "can't delete yourself", http.StatusBadRequest)
+			return
+		}
+		err := store.DeleteUser(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "user.delete", id.Hex(), nil, nil)
+		l.Sugar().Infof("user <%s> deleted", id.Hex())
+		http.Error(w, "user deleted", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Restore deleted user
+func Restore(_ *config.Config, store Store) http.HandlerFunc {
+	// Restore deleted user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		err := store.RestoreUser(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "deleted user not found", http.StatusNotFound)
+			return
+		} else if errors.Cause(err) == errs.ModelExists {
+			// Email of deleted user is used by new one
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "email already used", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "user.restore", id.Hex(), nil, nil)
+		l.Sugar().Infof("user <%s> restored", id.Hex())
+		http.Error(w, "user restored", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Unlock login of user locked after failed attempts
+func Unlock(_ *config.Config, store Store) http.HandlerFunc {
+	// Unlock login of user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		u, err := store.GetUserByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if err = store.DeleteLoginThrottles(ctx, []string{auth.AccountThrottleKey(u.Email)}); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "user.unlock", u.ID.Hex(), nil, nil)
+		l.Sugar().Infof("login of user <%s> unlocked", u.Email)
+		http.Error(w, "user unlocked", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// ResetTOTP disable two-factor authentication of user, who lost device
+// and recovery codes
+func ResetTOTP(_ *config.Config, store Store) http.HandlerFunc {
+	// Disable two-factor authentication of user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		err := store.DisableUserTOTP(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "user.totp.reset", id.Hex(), nil, nil)
+		l.Sugar().Infof("totp of user <%s> disabled", id.Hex())
+		http.Error(w, "totp disabled", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,582 @@
+package users_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/mail"
+	"github.com/l10n-center/api/src/model"
+	"github.com/l10n-center/api/src/users"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pressly/chi"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type mailerMock struct {
+	sent []*mail.Message
+}
+
+func (mm *mailerMock) Send(_ context.Context, m *mail.Message) error {
+	mm.sent = append(mm.sent, m)
+
+	return nil
+}
+
+func withNoopSpan(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		sp := opentracing.NoopTracer{}.StartSpan(r.URL.Path)
+		ctx := opentracing.ContextWithSpan(r.Context(), sp)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func TestRouter(t *testing.T) {
+	admin := &model.User{ID: bson.NewObjectId(), Email: "admin@email.com", IsAdmin: true}
+	user := &model.User{ID: bson.NewObjectId(), Email: "user@email.com"}
+	verified := time.Now()
+
+	cases := []struct {
+		name   string
+		store  func(*gomock.Controller) *MockStore
+		user   *model.User
+		method string
+		path   string
+		body   string
+		code   int
+		sent   int
+	}{
+		{
+			name: "No token",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			method: "GET",
+			path:   "/users",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "Not admin",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   user,
+			method: "GET",
+			path:   "/users",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "List",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUsers(gomock.Any()).
+					Return([]*model.User{admin, user}, nil)
+
+				return store
+			},
+			user:   admin,
+			method: "GET",
+			path:   "/users",
+			code:   http.StatusOK,
+		},
+		{
+			name: "List deleted",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetDeletedUsers(gomock.Any()).
+					Return([]*model.User{}, nil)
+
+				return store
+			},
+			user:   admin,
+			method: "GET",
+			path:   "/users/deleted",
+			code:   http.StatusOK,
+		},
+		{
+			name: "Get unknown",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			user:   admin,
+			method: "GET",
+			path:   "/users/" + user.ID.Hex(),
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Create duplicate",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Return(errs.ModelExists)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users",
+			body:   `{"email":"user@email.com","password":"correct horse"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Create",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Return(nil)
+				store.EXPECT().
+					SetUserEmailChange(gomock.Any(), gomock.Any(), "user@email.com", gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users",
+			body:   `{"email":"user@email.com","password":"correct horse","permission":2}`,
+			code:   http.StatusCreated,
+			sent:   1,
+		},
+		{
+			name: "Create with unknown role",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetRolesByNames(gomock.Any(), []string{"translator", "unknown"}).
+					Return([]*model.Role{{Name: "translator"}}, nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users",
+			body:   `{"email":"user@email.com","password":"correct horse","roles":["translator","unknown"]}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Update bad email",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   admin,
+			method: "PATCH",
+			path:   "/users/" + user.ID.Hex(),
+			body:   `{"email":"user"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Update",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(&model.User{ID: user.ID, Email: user.Email, EmailVerifiedAt: &user.CreatedAt}, nil)
+				store.EXPECT().
+					UpdateUser(gomock.Any(), user.ID, gomock.Any()).
+					Do(func(_ context.Context, _ bson.ObjectId, ch *model.UserChange) {
+						require.Equal(t, "new@email.com", *ch.Email)
+						require.Equal(t, model.Permission(6), *ch.Permission)
+						require.Nil(t, ch.Passhash)
+						require.Nil(t, ch.IsAdmin)
+						require.Nil(t, ch.Roles)
+					}).
+					Return(nil)
+				store.EXPECT().
+					SetUserEmailChange(gomock.Any(), user.ID, "new@email.com", gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "PATCH",
+			path:   "/users/" + user.ID.Hex(),
+			body:   `{"email":"new@email.com","permission":6}`,
+			code:   http.StatusOK,
+			sent:   1,
+		},
+		{
+			name: "Update password",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(&model.User{ID: user.ID, Email: user.Email, EmailVerifiedAt: &user.CreatedAt}, nil)
+				store.EXPECT().
+					UpdateUser(gomock.Any(), user.ID, gomock.Any()).
+					Do(func(_ context.Context, _ bson.ObjectId, ch *model.UserChange) {
+						require.NotNil(t, ch.Passhash)
+						require.Nil(t, ch.Email)
+					}).
+					Return(nil)
+				store.EXPECT().
+					DeleteUserSessions(gomock.Any(), user.ID).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "PATCH",
+			path:   "/users/" + user.ID.Hex(),
+			body:   `{"password":"correct horse battery"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Delete yourself",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   admin,
+			method: "DELETE",
+			path:   "/users/" + admin.ID.Hex(),
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Delete",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					DeleteUser(gomock.Any(), user.ID).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "DELETE",
+			path:   "/users/" + user.ID.Hex(),
+			code:   http.StatusOK,
+		},
+		{
+			name: "Restore not deleted",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					RestoreUser(gomock.Any(), user.ID).
+					Return(errs.ModelNotFound)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users/" + user.ID.Hex() + "/restore",
+			body:   `{}`,
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Restore email used",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					RestoreUser(gomock.Any(), user.ID).
+					Return(errs.ModelExists)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users/" + user.ID.Hex() + "/restore",
+			body:   `{}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name: "Set bad languages",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				return NewMockStore(ctrl)
+			},
+			user:   admin,
+			method: "PUT",
+			path:   "/users/" + user.ID.Hex() + "/languages",
+			body:   `["de","not a tag"]`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Set languages",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					SetUserLanguages(gomock.Any(), user.ID, []string{"de", "pt-BR"}).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "PUT",
+			path:   "/users/" + user.ID.Hex() + "/languages",
+			body:   `["de","pt-br","DE"]`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Sessions",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(user, nil)
+				store.EXPECT().
+					GetSessionsByUser(gomock.Any(), user.ID).
+					Return([]*model.Session{{ID: bson.NewObjectId(), UserID: user.ID}}, nil)
+
+				return store
+			},
+			user:   admin,
+			method: "GET",
+			path:   "/users/" + user.ID.Hex() + "/sessions",
+			code:   http.StatusOK,
+		},
+		{
+			name: "Revoke unknown session",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					DeleteSession(gomock.Any(), user.ID, gomock.Any()).
+					Return(errs.ModelNotFound)
+
+				return store
+			},
+			user:   admin,
+			method: "DELETE",
+			path:   "/users/" + user.ID.Hex() + "/sessions/" + bson.NewObjectId().Hex(),
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Revoke session",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					DeleteSession(gomock.Any(), user.ID, gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "DELETE",
+			path:   "/users/" + user.ID.Hex() + "/sessions/" + bson.NewObjectId().Hex(),
+			code:   http.StatusOK,
+		},
+		{
+			name: "Invite",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, u *model.User) {
+						require.NotEmpty(t, u.InviteNonce)
+						require.Empty(t, u.Passhash)
+					}).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users/invite",
+			body:   `{"email":"new@email.com","permission":6}`,
+			code:   http.StatusCreated,
+			sent:   1,
+		},
+		{
+			name: "Resend accepted invitation",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(&model.User{ID: user.ID, Email: user.Email}, nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users/" + user.ID.Hex() + "/invite",
+			body:   `{}`,
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Resend invitation",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(&model.User{ID: user.ID, Email: user.Email, InviteNonce: "nonce"}, nil)
+				store.EXPECT().
+					SetUserInvite(gomock.Any(), user.ID, gomock.Not("nonce"), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users/" + user.ID.Hex() + "/invite",
+			body:   `{}`,
+			code:   http.StatusOK,
+			sent:   1,
+		},
+		{
+			name: "Unlock unknown",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(nil, errs.ModelNotFound)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users/" + user.ID.Hex() + "/unlock",
+			body:   `{}`,
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Unlock",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(user, nil)
+				store.EXPECT().
+					DeleteLoginThrottles(gomock.Any(), []string{auth.AccountThrottleKey(user.Email)}).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users/" + user.ID.Hex() + "/unlock",
+			body:   `{}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Verify email",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(user, nil)
+				store.EXPECT().
+					VerifyUserEmail(gomock.Any(), user.ID).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users/" + user.ID.Hex() + "/verify",
+			body:   `{}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "Resend verification to verified",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(&model.User{ID: user.ID, Email: user.Email, EmailVerifiedAt: &verified}, nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users/" + user.ID.Hex() + "/verify/resend",
+			body:   `{}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "Resend verification",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), user.ID).
+					Return(user, nil)
+				store.EXPECT().
+					SetUserEmailChange(gomock.Any(), user.ID, user.Email, gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "POST",
+			path:   "/users/" + user.ID.Hex() + "/verify/resend",
+			body:   `{}`,
+			code:   http.StatusAccepted,
+			sent:   1,
+		},
+		{
+			name: "Revoke invitation",
+			store: func(ctrl *gomock.Controller) *MockStore {
+				store := NewMockStore(ctrl)
+				store.EXPECT().
+					RemoveInvitedUser(gomock.Any(), user.ID).
+					Return(nil)
+
+				return store
+			},
+			user:   admin,
+			method: "DELETE",
+			path:   "/users/" + user.ID.Hex() + "/invite",
+			code:   http.StatusOK,
+		},
+	}
+
+	mockCtrl := gomock.NewController(t)
+
+	defer mockCtrl.Finish()
+
+	cfg := config.Default()
+	keys, err := auth.NewKeyring(cfg)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(withNoopSpan)
+			mailer := &mailerMock{}
+			store := c.store(mockCtrl)
+			store.EXPECT().
+				CreateAuditEntry(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			r.Route("/users", users.Router(cfg, keys, store, mailer))
+
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			if c.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if c.user != nil {
+				st, err := auth.CreateToken(context.Background(), keys, nil, c.user)
+				require.NoError(t, err)
+				req.Header.Set("Authorization", "Bearer "+st)
+				store.EXPECT().
+					IsTokenRevoked(gomock.Any(), gomock.Any()).
+					Return(false, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), c.user.ID).
+					Return(c.user, nil)
+			}
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			require.Equal(t, c.code, res.Code, res.Body.String())
+			require.Len(t, mailer.sent, c.sent)
+		})
+	}
+}
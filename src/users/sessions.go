@@ -0,0 +1,81 @@
+package users
+
+import (
+	"net/http"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/pkg/errors"
+	"github.com/pressly/chi"
+	"github.com/pressly/chi/render"
+	"go.uber.org/zap"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Sessions of user
+func Sessions(_ *config.Config, store Store) http.HandlerFunc {
+	// Sessions of user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		_, err := store.GetUserByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		sl, err := store.GetSessionsByUser(ctx, id)
+		if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, r, sl)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// DeleteSession revoke session of user with all it's tokens
+func DeleteSession(_ *config.Config, store Store) http.HandlerFunc {
+	// Revoke session of user with all it's tokens
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		sid := chi.URLParam(r, "sessionId")
+		if !ok || !bson.IsObjectIdHex(sid) {
+			l.Debug("bad user or session id")
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		err := store.DeleteSession(ctx, id, bson.ObjectIdHex(sid))
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "session.revoke", sid, nil, map[string]string{"userId": id.Hex()})
+		l.Sugar().Infof("session <%s> of user <%s> revoked", sid, id.Hex())
+		http.Error(w, "session revoked", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,37 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/model"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+//go:generate mockgen -source=store.go -destination=store_mock_test.go -package=users_test -aux_files=auth=../auth/store.go
+
+// Store is a interface of store required in package users
+type Store interface {
+	auth.ClaimsStore
+	auth.AuditStore
+	auth.VerifyStore
+	GetUsers(context.Context) ([]*model.User, error)
+	CreateUser(context.Context, *model.User) error
+	UpdateUser(context.Context, bson.ObjectId, *model.UserChange) error
+	DeleteUser(context.Context, bson.ObjectId) error
+	RestoreUser(context.Context, bson.ObjectId) error
+	GetDeletedUsers(context.Context) ([]*model.User, error)
+	PurgeDeletedUsers(context.Context, time.Time) (int, error)
+	SetUserLanguages(context.Context, bson.ObjectId, []string) error
+	DisableUserTOTP(context.Context, bson.ObjectId) error
+	DeleteLoginThrottles(context.Context, []string) error
+	GetSessionsByUser(context.Context, bson.ObjectId) ([]*model.Session, error)
+	DeleteSession(context.Context, bson.ObjectId, bson.ObjectId) error
+	DeleteUserSessions(context.Context, bson.ObjectId) error
+	GetInvitedUsers(context.Context) ([]*model.User, error)
+	SetUserInvite(context.Context, bson.ObjectId, string, time.Time) error
+	RemoveInvitedUser(context.Context, bson.ObjectId) error
+	VerifyUserEmail(context.Context, bson.ObjectId) error
+}
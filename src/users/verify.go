@@ -0,0 +1,90 @@
+package users
+
+import (
+	"net/http"
+
+	"github.com/l10n-center/api/src/auth"
+	"github.com/l10n-center/api/src/config"
+	"github.com/l10n-center/api/src/errs"
+	"github.com/l10n-center/api/src/mail"
+	"github.com/l10n-center/api/src/tracing"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// VerifyEmail mark email of user as verified, when user can't receive
+// verification token
+func VerifyEmail(_ *config.Config, store Store) http.HandlerFunc {
+	// Mark email of user as verified
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		u, err := store.GetUserByID(ctx, id)
+		if err == nil {
+			err = store.VerifyUserEmail(ctx, id)
+		}
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "user.verify", u.ID.Hex(), nil, map[string]string{"email": u.Email})
+		l.Sugar().Infof("email <%s> marked as verified", u.Email)
+		http.Error(w, "email verified", http.StatusOK)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// ResendVerification send new verification token to not verified
+// or pending email of user
+func ResendVerification(_ *config.Config, store Store, mailer mail.Mailer) http.HandlerFunc {
+	// Send new verification token to email of user
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := tracing.Logger(ctx)
+
+		id, ok := userID(r)
+		if !ok {
+			l.Debug("bad user id")
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		u, err := store.GetUserByID(ctx, id)
+		if errors.Cause(err) == errs.ModelNotFound {
+			l.Debug(err.Error(), zap.Error(err))
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		email := auth.VerificationEmail(u)
+		if email == "" {
+			l.Debug("email already verified")
+			http.Error(w, "email already verified", http.StatusBadRequest)
+			return
+		}
+		if err = auth.SendVerification(ctx, store, mailer, u, email); err != nil {
+			l.Error(err.Error(), errs.ZapStack(err))
+			http.Error(w, errs.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+		auth.Audit(ctx, store, r, "user.verify.resend", u.ID.Hex(), nil, nil)
+		l.Sugar().Infof("verification token sent to <%s>", email)
+		http.Error(w, "verification token sent", http.StatusAccepted)
+	}
+	return http.HandlerFunc(fn)
+}